@@ -0,0 +1,290 @@
+package contentzen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BulkOptions configures bulk document operations.
+type BulkOptions struct {
+	// Concurrency is the number of requests run in parallel. Defaults to 4.
+	Concurrency int
+	// RateLimit caps the number of requests started per second across all
+	// workers. Zero means no limit.
+	RateLimit float64
+}
+
+// BulkResult is the outcome of a single item in a bulk operation.
+type BulkResult struct {
+	// Index is the position of the item in the input slice or channel.
+	Index int
+	// UUID is the document UUID: the one created by the server for creates,
+	// or the one that was updated or deleted.
+	UUID string
+	// Document is the document returned by the server for creates and updates.
+	Document *Document
+	// Err is non-nil if the item failed; it is always a *BulkError.
+	Err error
+}
+
+// BulkError describes the failure of a single item in a bulk operation.
+type BulkError struct {
+	Op    string
+	Index int
+	UUID  string
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	if e.UUID != "" {
+		return fmt.Sprintf("bulk %s item %d (%s): %v", e.Op, e.Index, e.UUID, e.Err)
+	}
+	return fmt.Sprintf("bulk %s item %d: %v", e.Op, e.Index, e.Err)
+}
+
+func (e *BulkError) Unwrap() error { return e.Err }
+
+// BulkReport collects the results of a bulk operation, ordered by input index.
+type BulkReport struct {
+	Results   []BulkResult
+	Succeeded int
+	Failed    int
+}
+
+// Errors returns the errors of all failed items.
+func (r *BulkReport) Errors() []error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	return errs
+}
+
+// BulkCreateDocuments creates docs in a collection (requires API token).
+// Individual failures are reported in the returned report and do not stop
+// the remaining items; the error is only non-nil if ctx was cancelled.
+func (c *Client) BulkCreateDocuments(ctx context.Context, collectionUUID string, docs []*Document, opts *BulkOptions) (*BulkReport, error) {
+	return collectBulk(ctx, len(docs), c.bulkCreate(ctx, collectionUUID, sliceChan(ctx, docs), opts, false))
+}
+
+// BulkUpdateDocuments updates docs in a collection, using each document's
+// UUID to address it (requires API token).
+func (c *Client) BulkUpdateDocuments(ctx context.Context, collectionUUID string, docs []*Document, opts *BulkOptions) (*BulkReport, error) {
+	return collectBulk(ctx, len(docs), c.bulkUpdate(ctx, collectionUUID, sliceChan(ctx, docs), opts, false))
+}
+
+// BulkDeleteDocuments deletes the documents with the given UUIDs (requires API token).
+func (c *Client) BulkDeleteDocuments(ctx context.Context, collectionUUID string, documentUUIDs []string, opts *BulkOptions) (*BulkReport, error) {
+	return collectBulk(ctx, len(documentUUIDs), c.bulkDelete(ctx, collectionUUID, sliceChan(ctx, documentUUIDs), opts, false))
+}
+
+// BulkCreateDocumentsStream creates documents read from docs until it is
+// closed or ctx is cancelled. Results are delivered in completion order on
+// the returned channel, which is closed once all work has finished. Once
+// ctx is cancelled, in-flight requests are aborted and results that the
+// caller does not read are discarded, so the caller may stop reading. The
+// outcome of an item whose result was discarded is unknown: its request
+// may have completed before the cancellation.
+func (c *Client) BulkCreateDocumentsStream(ctx context.Context, collectionUUID string, docs <-chan *Document, opts *BulkOptions) <-chan BulkResult {
+	return c.bulkCreate(ctx, collectionUUID, docs, opts, true)
+}
+
+func (c *Client) bulkCreate(ctx context.Context, collectionUUID string, docs <-chan *Document, opts *BulkOptions, lossy bool) <-chan BulkResult {
+	return runBulk(ctx, docs, opts, lossy, func(i int, doc *Document) BulkResult {
+		if doc == nil {
+			return BulkResult{Index: i, Err: &BulkError{Op: "create", Index: i, Err: fmt.Errorf("document is nil")}}
+		}
+		created, err := c.createDocument(ctx, collectionUUID, doc)
+		if err != nil {
+			return BulkResult{Index: i, Err: &BulkError{Op: "create", Index: i, Err: err}}
+		}
+		return BulkResult{Index: i, UUID: created.UUID, Document: created}
+	})
+}
+
+// BulkUpdateDocumentsStream updates documents read from docs until it is
+// closed or ctx is cancelled.
+func (c *Client) BulkUpdateDocumentsStream(ctx context.Context, collectionUUID string, docs <-chan *Document, opts *BulkOptions) <-chan BulkResult {
+	return c.bulkUpdate(ctx, collectionUUID, docs, opts, true)
+}
+
+func (c *Client) bulkUpdate(ctx context.Context, collectionUUID string, docs <-chan *Document, opts *BulkOptions, lossy bool) <-chan BulkResult {
+	return runBulk(ctx, docs, opts, lossy, func(i int, doc *Document) BulkResult {
+		if doc == nil {
+			return BulkResult{Index: i, Err: &BulkError{Op: "update", Index: i, Err: fmt.Errorf("document is nil")}}
+		}
+		if doc.UUID == "" {
+			return BulkResult{Index: i, Err: &BulkError{Op: "update", Index: i, Err: fmt.Errorf("document UUID is required")}}
+		}
+		updated, err := c.updateDocument(ctx, collectionUUID, doc.UUID, doc)
+		if err != nil {
+			return BulkResult{Index: i, UUID: doc.UUID, Err: &BulkError{Op: "update", Index: i, UUID: doc.UUID, Err: err}}
+		}
+		return BulkResult{Index: i, UUID: doc.UUID, Document: updated}
+	})
+}
+
+// BulkDeleteDocumentsStream deletes the documents whose UUIDs are read from
+// documentUUIDs until it is closed or ctx is cancelled.
+func (c *Client) BulkDeleteDocumentsStream(ctx context.Context, collectionUUID string, documentUUIDs <-chan string, opts *BulkOptions) <-chan BulkResult {
+	return c.bulkDelete(ctx, collectionUUID, documentUUIDs, opts, true)
+}
+
+func (c *Client) bulkDelete(ctx context.Context, collectionUUID string, documentUUIDs <-chan string, opts *BulkOptions, lossy bool) <-chan BulkResult {
+	return runBulk(ctx, documentUUIDs, opts, lossy, func(i int, uuid string) BulkResult {
+		if uuid == "" {
+			return BulkResult{Index: i, Err: &BulkError{Op: "delete", Index: i, Err: fmt.Errorf("document UUID is required")}}
+		}
+		if err := c.deleteDocument(ctx, collectionUUID, uuid); err != nil {
+			return BulkResult{Index: i, UUID: uuid, Err: &BulkError{Op: "delete", Index: i, UUID: uuid, Err: err}}
+		}
+		return BulkResult{Index: i, UUID: uuid}
+	})
+}
+
+// runBulk fans items out to a bounded pool of workers, optionally throttled
+// by a shared ticker, and fans their results back into a single channel.
+// If lossy is set, results are dropped once ctx is done, for callers that
+// may stop reading; otherwise every result is delivered, and the caller
+// must drain the channel.
+func runBulk[T any](ctx context.Context, items <-chan T, opts *BulkOptions, lossy bool, do func(int, T) BulkResult) <-chan BulkResult {
+	concurrency := 4
+	var rateLimit float64
+	if opts != nil {
+		if opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+		rateLimit = opts.RateLimit
+	}
+
+	type job struct {
+		index int
+		item  T
+	}
+	jobs := make(chan job)
+	results := make(chan BulkResult)
+
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if rateLimit > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / rateLimit))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i := 0; ; i++ {
+			var item T
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok = <-items:
+				if !ok {
+					return
+				}
+			}
+			if tick != nil && i > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- job{index: i, item: item}:
+			}
+		}
+	}()
+
+	go func() {
+		defer close(results)
+		runWorkers(ctx, jobs, concurrency, func(j job) {
+			res := do(j.index, j.item)
+			if !lossy {
+				results <- res
+				return
+			}
+			select {
+			case results <- res:
+			case <-ctx.Done():
+				// The caller may have stopped reading; drop the result.
+			}
+		})
+	}()
+	return results
+}
+
+// runWorkers calls fn for the items received from items with at most
+// concurrency calls in flight, until items is closed or ctx is done. It
+// returns once all calls have returned.
+func runWorkers[T any](ctx context.Context, items <-chan T, concurrency int, fn func(T)) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case item, ok := <-items:
+					if !ok {
+						return
+					}
+					fn(item)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// sliceChan streams the elements of s on a channel until ctx is cancelled.
+func sliceChan[T any](ctx context.Context, s []T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, v := range s {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- v:
+			}
+		}
+	}()
+	return ch
+}
+
+// collectBulk drains results into a report ordered by input index.
+func collectBulk(ctx context.Context, n int, results <-chan BulkResult) (*BulkReport, error) {
+	report := &BulkReport{Results: make([]BulkResult, n)}
+	seen := make([]bool, n)
+	for res := range results {
+		report.Results[res.Index] = res
+		seen[res.Index] = true
+		if res.Err != nil {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		for i := range report.Results {
+			if !seen[i] {
+				report.Results[i] = BulkResult{Index: i, Err: &BulkError{Op: "bulk", Index: i, Err: err}}
+				report.Failed++
+			}
+		}
+		return report, err
+	}
+	return report, nil
+}
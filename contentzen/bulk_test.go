@@ -0,0 +1,164 @@
+package contentzen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+	"github.com/contentzen-hub/sdk-go/contentzentest"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func newBulkTest(t *testing.T) (*contentzentest.Server, *contentzen.Client, string) {
+	t.Helper()
+	srv := contentzentest.NewServer()
+	t.Cleanup(srv.Close)
+	col := srv.AddCollection(contentzen.Collection{
+		Name:   "posts",
+		Fields: []contentzen.CollectionField{{Name: "title", Type: contentzen.FieldTypeText, Required: true}},
+	})
+	return srv, srv.Client(), col.UUID
+}
+
+func titled(n int) []*contentzen.Document {
+	docs := make([]*contentzen.Document, n)
+	for i := range docs {
+		docs[i] = &contentzen.Document{Payload: map[string]interface{}{"title": fmt.Sprintf("post %d", i)}}
+	}
+	return docs
+}
+
+func TestBulkCreateOrder(t *testing.T) {
+	srv, c, col := newBulkTest(t)
+	report, err := c.BulkCreateDocuments(context.Background(), col, titled(20), &contentzen.BulkOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 20 || report.Failed != 0 {
+		t.Fatalf("succeeded %d, failed %d, errors %v", report.Succeeded, report.Failed, report.Errors())
+	}
+	for i, res := range report.Results {
+		if res.Index != i || res.Document == nil || res.UUID != res.Document.UUID {
+			t.Fatalf("result %d = %+v", i, res)
+		}
+		if got, want := res.Document.Payload["title"], fmt.Sprintf("post %d", i); got != want {
+			t.Errorf("result %d has title %v, want %s", i, got, want)
+		}
+	}
+	if n := len(srv.Documents(col)); n != 20 {
+		t.Errorf("server has %d documents, want 20", n)
+	}
+}
+
+func TestBulkPartialFailure(t *testing.T) {
+	_, c, col := newBulkTest(t)
+	ctx := context.Background()
+	docs := titled(4)
+	docs[1] = &contentzen.Document{Payload: map[string]interface{}{}}
+	docs[2] = nil
+	report, err := c.BulkCreateDocuments(ctx, col, docs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 2 || report.Failed != 2 {
+		t.Fatalf("succeeded %d, failed %d", report.Succeeded, report.Failed)
+	}
+	for _, i := range []int{1, 2} {
+		var be *contentzen.BulkError
+		if !errors.As(report.Results[i].Err, &be) || be.Op != "create" || be.Index != i {
+			t.Errorf("result %d error = %v", i, report.Results[i].Err)
+		}
+	}
+
+	updates := []*contentzen.Document{
+		{UUID: report.Results[0].UUID, Payload: map[string]interface{}{"title": "updated"}},
+		{UUID: "missing", Payload: map[string]interface{}{"title": "x"}},
+		{Payload: map[string]interface{}{"title": "no uuid"}},
+	}
+	report, err = c.BulkUpdateDocuments(ctx, col, updates, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 1 || report.Results[1].Err == nil || report.Results[2].Err == nil {
+		t.Errorf("update report = %+v", report)
+	}
+
+	report, err = c.BulkDeleteDocuments(ctx, col, []string{updates[0].UUID, ""}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 1 || report.Results[1].Err == nil {
+		t.Errorf("delete report = %+v", report)
+	}
+}
+
+func TestBulkCancel(t *testing.T) {
+	srv, c, col := newBulkTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests atomic.Int32
+	transport := c.HTTPClient.Transport
+	c.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		// Read the body first, so that the cancellation only affects the
+		// requests that follow.
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if requests.Add(1) == 3 {
+			cancel()
+		}
+		return resp, nil
+	})}
+
+	report, err := c.BulkCreateDocuments(ctx, col, titled(10), &contentzen.BulkOptions{Concurrency: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if report.Succeeded+report.Failed != 10 {
+		t.Errorf("succeeded %d + failed %d, want 10", report.Succeeded, report.Failed)
+	}
+	// Every created document is reported as created, so that retrying the
+	// failed items does not create duplicates.
+	if n := len(srv.Documents(col)); n != report.Succeeded || n < 3 {
+		t.Errorf("server has %d documents, report has %d successes", n, report.Succeeded)
+	}
+	for _, err := range report.Errors() {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+}
+
+func TestBulkRateLimit(t *testing.T) {
+	_, c, col := newBulkTest(t)
+	start := time.Now()
+	report, err := c.BulkCreateDocuments(context.Background(), col, titled(5), &contentzen.BulkOptions{Concurrency: 5, RateLimit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 5 {
+		t.Fatalf("succeeded %d, errors %v", report.Succeeded, report.Errors())
+	}
+	// Five requests at 20 per second are spread over at least four ticks.
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("5 requests took %v, want at least 200ms", elapsed)
+	}
+}
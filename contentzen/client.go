@@ -12,6 +12,9 @@ type Client struct {
 	BaseURL    string
 	APIToken   string
 	HTTPClient *http.Client
+	// UploadPolicy, if set, is enforced on uploads that do not specify
+	// their own UploadOptions.Policy.
+	UploadPolicy *UploadPolicy
 }
 
 // NewClient creates a new ContentZen API client. If apiToken is empty, only public endpoints are available.
@@ -0,0 +1,72 @@
+package contentzen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ErrConflict matches a *ConflictError with errors.Is.
+var ErrConflict = errors.New("version conflict")
+
+// MaxConflictRetries is the number of times UpdateDocumentWithRetry
+// re-reads and reapplies its change after a conflict before giving up.
+const MaxConflictRetries = 5
+
+// ConflictError is returned when an update is rejected because the
+// resource was changed by someone else after Version was read.
+type ConflictError struct {
+	// Kind is "document" or "collection".
+	Kind    string
+	UUID    string
+	Version int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s was modified after version %d", e.Kind, e.UUID, e.Version)
+}
+
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// ETag returns the entity tag of a resource version, as sent in If-Match
+// headers.
+func ETag(version int64) string {
+	return fmt.Sprintf("%q", fmt.Sprint(version))
+}
+
+// UpdateDocumentWithRetry reads a document, applies mutate to it and writes
+// it back conditionally on the version that was read (requires API token).
+// If another writer changed the document in between, the document is read
+// again and mutate reapplied, up to MaxConflictRetries times. mutate must
+// therefore be safe to call more than once; an error from it aborts the
+// update and is returned as is.
+func (c *Client) UpdateDocumentWithRetry(ctx context.Context, collectionUUID, documentUUID string, mutate func(*Document) error) (*Document, error) {
+	backoff := 50 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		doc, err := c.getDocument(ctx, collectionUUID, documentUUID)
+		if err != nil {
+			return nil, err
+		}
+		version := doc.Version
+		if err := mutate(doc); err != nil {
+			return nil, err
+		}
+		doc.Version = version
+		updated, err := c.updateDocument(ctx, collectionUUID, documentUUID, doc)
+		if !errors.Is(err, ErrConflict) || attempt == MaxConflictRetries {
+			return updated, err
+		}
+		// Spread retries out so that competing writers do not collide again.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff/2 + time.Duration(rand.Int63n(int64(backoff)))):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,198 @@
+package contentzen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeOp is the kind of a Change.
+type ChangeOp string
+
+// Kinds of changes reported by DiffDocuments.
+const (
+	ChangeAdded   ChangeOp = "added"
+	ChangeRemoved ChangeOp = "removed"
+	ChangeChanged ChangeOp = "changed"
+)
+
+// Change is a single difference between two documents.
+type Change struct {
+	Op ChangeOp `json:"op"`
+	// Path is a JSON Pointer to the changed value within the document,
+	// such as "/state" or "/payload/tags/2".
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff is the list of changes between two documents, in the order they
+// must be applied.
+type Diff []Change
+
+// DiffDocuments returns the changes that turn document a into document b:
+// changes to Lang and State, followed by the payload values that were
+// added, removed or changed. Nested objects are compared key by key, in
+// key order, and arrays element by element; elements added to the end of
+// an array are reported in ascending and removed ones in descending index
+// order, so that the diff can be applied as a patch.
+func DiffDocuments(a, b *Document) Diff {
+	var d Diff
+	if a.Lang != b.Lang {
+		d = append(d, Change{Op: ChangeChanged, Path: "/lang", From: a.Lang, To: b.Lang})
+	}
+	if a.State != b.State {
+		d = append(d, Change{Op: ChangeChanged, Path: "/state", From: a.State, To: b.State})
+	}
+	from := normalizeJSON(a.Payload)
+	to := normalizeJSON(b.Payload)
+	if from == nil {
+		from = map[string]interface{}{}
+	}
+	if to == nil {
+		to = map[string]interface{}{}
+	}
+	return diffValues(d, "/payload", from, to)
+}
+
+func diffValues(d Diff, path string, a, b interface{}) Diff {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			return diffObjects(d, path, av, bv)
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			return diffArrays(d, path, av, bv)
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		d = append(d, Change{Op: ChangeChanged, Path: path, From: a, To: b})
+	}
+	return d
+}
+
+func diffObjects(d Diff, path string, a, b map[string]interface{}) Diff {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		p := path + "/" + escapePointer(k)
+		switch {
+		case !inA:
+			d = append(d, Change{Op: ChangeAdded, Path: p, To: bv})
+		case !inB:
+			d = append(d, Change{Op: ChangeRemoved, Path: p, From: av})
+		default:
+			d = diffValues(d, p, av, bv)
+		}
+	}
+	return d
+}
+
+func diffArrays(d Diff, path string, a, b []interface{}) Diff {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		d = diffValues(d, path+"/"+strconv.Itoa(i), a[i], b[i])
+	}
+	for i := n; i < len(b); i++ {
+		d = append(d, Change{Op: ChangeAdded, Path: path + "/" + strconv.Itoa(i), To: b[i]})
+	}
+	for i := len(a) - 1; i >= n; i-- {
+		d = append(d, Change{Op: ChangeRemoved, Path: path + "/" + strconv.Itoa(i), From: a[i]})
+	}
+	return d
+}
+
+// Text renders the diff with one line per change, prefixed with "+" for
+// added, "-" for removed and "~" for changed values:
+//
+//	~ /payload/title: "Draft" -> "Final"
+//	+ /payload/tags/2: "news"
+//	- /payload/subtitle: "Old"
+func (d Diff) Text() string {
+	var sb strings.Builder
+	for _, c := range d {
+		switch c.Op {
+		case ChangeAdded:
+			fmt.Fprintf(&sb, "+ %s: %s\n", c.Path, renderValue(c.To))
+		case ChangeRemoved:
+			fmt.Fprintf(&sb, "- %s: %s\n", c.Path, renderValue(c.From))
+		default:
+			fmt.Fprintf(&sb, "~ %s: %s -> %s\n", c.Path, renderValue(c.From), renderValue(c.To))
+		}
+	}
+	return sb.String()
+}
+
+// String implements fmt.Stringer using Text.
+func (d Diff) String() string { return d.Text() }
+
+// JSON renders the diff as an indented JSON array of changes.
+func (d Diff) JSON() ([]byte, error) {
+	if d == nil {
+		d = Diff{}
+	}
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Patch returns a JSON Patch that applies the diff to the first document
+// it was computed from.
+func (d Diff) Patch() JSONPatch {
+	patch := make(JSONPatch, 0, len(d))
+	for _, c := range d {
+		switch c.Op {
+		case ChangeAdded:
+			patch = patch.Add(c.Path, c.To)
+		case ChangeRemoved:
+			patch = patch.Remove(c.Path)
+		default:
+			patch = patch.Replace(c.Path, c.To)
+		}
+	}
+	return patch
+}
+
+func renderValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// escapePointer escapes a key for use as a JSON Pointer reference token.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// equalJSON reports whether a and b encode to the same JSON value, so that
+// for example int(1) and float64(1) compare equal.
+func equalJSON(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+// normalizeJSON converts v to the types produced by decoding JSON.
+func normalizeJSON(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
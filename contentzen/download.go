@@ -0,0 +1,252 @@
+package contentzen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ChecksumHeader is the response header carrying the hex SHA-256 digest of
+// a media file.
+const ChecksumHeader = "X-Checksum-Sha256"
+
+// DownloadOptions configures a media download.
+type DownloadOptions struct {
+	// Progress, if set, receives progress reports as the file is received.
+	Progress ProgressFunc
+	// Resume keeps the partial file of a failed DownloadMediaFile and
+	// continues from it on the next call using an HTTP Range request. The
+	// request carries the ETag or Last-Modified date of the partial file in
+	// If-Range, so the download starts over if the media has changed.
+	Resume bool
+	// VerifyChecksum checks the downloaded bytes against the SHA-256 digest
+	// reported by the server in ChecksumHeader.
+	VerifyChecksum bool
+	// Checksum is an expected hex SHA-256 digest to verify, for example
+	// Media.Checksum. It takes precedence over the server-reported digest.
+	Checksum string
+}
+
+// ChecksumError is returned when downloaded bytes do not match the
+// expected digest.
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected sha256 %s, got %s", e.Expected, e.Actual)
+}
+
+// DownloadMediaTo writes a media file to w (requires API token).
+func (c *Client) DownloadMediaTo(ctx context.Context, mediaUUID string, w io.Writer, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	resp, err := c.requestMediaDownload(ctx, mediaUUID, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	expected, err := expectedChecksum(resp, opts)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), downloadBody(resp, 0, opts)); err != nil {
+		return err
+	}
+	return verifyChecksum(expected, h)
+}
+
+// DownloadMediaFile downloads a media file by UUID to destPath (requires
+// API token). The file is written to destPath+".part" and renamed into
+// place once complete and verified, so destPath never holds a truncated
+// file. With opts.Resume, a partial file left by an earlier call is
+// continued instead of downloaded again, provided the media is unchanged.
+// The validator of the partial file is kept next to it in a ".validator"
+// file.
+func (c *Client) DownloadMediaFile(ctx context.Context, mediaUUID, destPath string, opts *DownloadOptions) (err error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	partPath := destPath + ".part"
+	validatorPath := partPath + ".validator"
+
+	var offset int64
+	var validator string
+	if opts.Resume {
+		if b, readErr := os.ReadFile(validatorPath); readErr == nil {
+			validator = string(b)
+		}
+		// Without a validator the partial file cannot be checked against
+		// the current media, so it is downloaded again.
+		if info, statErr := os.Stat(partPath); statErr == nil && validator != "" {
+			offset = info.Size()
+		}
+	}
+	resp, err := c.requestMediaDownload(ctx, mediaUUID, offset, validator)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The offset is at or past the end of the media: the partial file
+		// is complete but was not renamed, or does not belong to the
+		// current media. Start over.
+		resp.Body.Close()
+		os.Remove(partPath)
+		os.Remove(validatorPath)
+		return c.DownloadMediaFile(ctx, mediaUUID, destPath, &DownloadOptions{
+			Progress:       opts.Progress,
+			VerifyChecksum: opts.VerifyChecksum,
+			Checksum:       opts.Checksum,
+		})
+	case resp.StatusCode == http.StatusOK:
+		// The media changed since the partial file was written, or the
+		// server ignored the range.
+		offset = 0
+	default:
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	expected, err := expectedChecksum(resp, opts)
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_CREATE | os.O_RDWR
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	out, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if out != nil {
+			out.Close()
+		}
+		if err != nil && (!opts.Resume || errors.As(err, new(*ChecksumError))) {
+			os.Remove(partPath)
+			os.Remove(validatorPath)
+		}
+	}()
+	if opts.Resume {
+		if v := downloadValidator(resp); v != "" {
+			if err := os.WriteFile(validatorPath, []byte(v), 0o644); err != nil {
+				return err
+			}
+		} else {
+			os.Remove(validatorPath)
+		}
+	}
+
+	h := sha256.New()
+	if offset > 0 {
+		if _, err := io.Copy(h, io.LimitReader(out, offset)); err != nil {
+			return err
+		}
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.MultiWriter(out, h), downloadBody(resp, offset, opts)); err != nil {
+		return err
+	}
+	if err := verifyChecksum(expected, h); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		out = nil
+		return err
+	}
+	out = nil
+	if err := os.Rename(partPath, destPath); err != nil {
+		return err
+	}
+	os.Remove(validatorPath)
+	return nil
+}
+
+// requestMediaDownload requests a media file starting at offset. The range
+// is conditional on validator, if set.
+func (c *Client) requestMediaDownload(ctx context.Context, mediaUUID string, offset int64, validator string) (*http.Response, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/media/%s/download", c.BaseURL, mediaUUID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// downloadValidator returns the validator of resp usable in If-Range: its
+// ETag if it is strong, or its Last-Modified date.
+func downloadValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// downloadBody wraps the response body with progress reporting if requested.
+func downloadBody(resp *http.Response, offset int64, opts *DownloadOptions) io.Reader {
+	if opts.Progress == nil {
+		return resp.Body
+	}
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	pr := newProgressReader(resp.Body, total, opts.Progress)
+	pr.tracker.resume(offset)
+	return pr
+}
+
+func expectedChecksum(resp *http.Response, opts *DownloadOptions) (string, error) {
+	if opts.Checksum != "" {
+		return strings.ToLower(opts.Checksum), nil
+	}
+	if !opts.VerifyChecksum {
+		return "", nil
+	}
+	sum := resp.Header.Get(ChecksumHeader)
+	if sum == "" {
+		return "", fmt.Errorf("server did not report a checksum")
+	}
+	return strings.ToLower(sum), nil
+}
+
+func verifyChecksum(expected string, h hash.Hash) error {
+	if expected == "" {
+		return nil
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return &ChecksumError{Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package contentzen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// ExpandOptions configures reference expansion.
+type ExpandOptions struct {
+	// Depth is the number of levels of references expanded. Defaults to 1,
+	// which expands the references of the given documents but not those of
+	// the documents they reference.
+	Depth int
+	// Fields limits expansion to the named fields. By default every
+	// reference and media field is expanded.
+	Fields []string
+	// Public resolves referenced documents through the public API, so that
+	// only published documents are embedded. Referenced collections must
+	// then be public. The API token is still needed to read the fields of
+	// the collections, and media fields are left as UUIDs because media
+	// are only available through the private API.
+	Public bool
+	// Concurrency is the number of lookups run in parallel. Defaults to 4.
+	Concurrency int
+}
+
+// ExpandDocuments replaces the UUIDs stored in the reference and media
+// fields of docs, as declared by the collection's fields, with the
+// referenced *Document and *Media (requires API token). A field holding a
+// list of UUIDs becomes a []interface{} of the same length.
+//
+// Each referenced collection is listed once, when it is first referenced,
+// and the media library is listed once if a media field is expanded, so
+// the cost grows with the number of referenced collections rather than
+// with the number of references. Documents and media missing from those
+// listings are then fetched one by one. References that cannot be
+// resolved, and references back to a document that is already being
+// expanded higher up, are left as UUIDs.
+func (c *Client) ExpandDocuments(ctx context.Context, collectionUUID string, docs []Document, opts *ExpandOptions) error {
+	if c.APIToken == "" {
+		return fmt.Errorf("API token required for this endpoint")
+	}
+	o := ExpandOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Depth <= 0 {
+		o.Depth = 1
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	x := &expander{
+		c:      c,
+		opts:   o,
+		fields: make(map[string][]CollectionField),
+		docs:   make(map[docRef]*Document),
+		media:  make(map[string]*Media),
+		listed: make(map[string]bool),
+	}
+
+	var frontier []expandNode
+	for i := range docs {
+		if docs[i].Payload != nil {
+			frontier = append(frontier, expandNode{
+				collection: collectionUUID,
+				payload:    docs[i].Payload,
+				ancestors:  map[string]bool{docs[i].UUID: true},
+			})
+		}
+	}
+	for level := 0; level < o.Depth && len(frontier) > 0; level++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := x.load(ctx, frontier); err != nil {
+			return err
+		}
+		frontier = x.replace(frontier)
+	}
+	return ctx.Err()
+}
+
+// ExpandDocument expands the references of a single document, as
+// ExpandDocuments does.
+func (c *Client) ExpandDocument(ctx context.Context, collectionUUID string, doc *Document, opts *ExpandOptions) error {
+	if doc == nil {
+		return fmt.Errorf("document is nil")
+	}
+	docs := []Document{*doc}
+	err := c.ExpandDocuments(ctx, collectionUUID, docs, opts)
+	doc.Payload = docs[0].Payload
+	return err
+}
+
+// expandNode is a payload waiting to have its references expanded.
+type expandNode struct {
+	collection string
+	payload    map[string]interface{}
+	// ancestors holds the UUIDs of the documents on the path from the root
+	// document to this one, to detect cycles.
+	ancestors map[string]bool
+}
+
+// docRef identifies a referenced document.
+type docRef struct {
+	collection string
+	uuid       string
+}
+
+type expander struct {
+	c    *Client
+	opts ExpandOptions
+
+	mu     sync.Mutex
+	fields map[string][]CollectionField
+	// docs and media hold the fetched documents and media, nil for those
+	// that do not exist.
+	docs  map[docRef]*Document
+	media map[string]*Media
+	// listed holds the collections whose documents were listed into docs,
+	// and mediaListed whether the media library was listed into media.
+	listed      map[string]bool
+	mediaListed bool
+}
+
+// load fetches the schemas of the frontier's collections, then every
+// document and media file they reference that has not been fetched yet:
+// first by listing the referenced collections and the media library, then
+// one by one for those the listings did not return.
+func (x *expander) load(ctx context.Context, frontier []expandNode) error {
+	var schemas []string
+	for _, n := range frontier {
+		if _, ok := x.fields[n.collection]; !ok && !slices.Contains(schemas, n.collection) {
+			schemas = append(schemas, n.collection)
+		}
+	}
+	if err := expandParallel(ctx, x.opts.Concurrency, schemas, func(col string) error {
+		fields, err := x.c.getCollectionFields(ctx, col)
+		if err != nil {
+			return fmt.Errorf("get fields of collection %s: %w", col, err)
+		}
+		x.mu.Lock()
+		x.fields[col] = fields
+		x.mu.Unlock()
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var docs []docRef
+	var media []string
+	for _, n := range frontier {
+		for _, f := range x.referenceFields(n.collection) {
+			for _, id := range referencedUUIDs(n.payload[f.Name]) {
+				if f.Type == FieldTypeMedia {
+					if _, ok := x.media[id]; !ok && !slices.Contains(media, id) {
+						media = append(media, id)
+					}
+					continue
+				}
+				ref := docRef{collection: f.Collection, uuid: id}
+				if _, ok := x.docs[ref]; !ok && !slices.Contains(docs, ref) {
+					docs = append(docs, ref)
+				}
+			}
+		}
+	}
+	var collections []string
+	for _, ref := range docs {
+		if !x.listed[ref.collection] && !slices.Contains(collections, ref.collection) {
+			collections = append(collections, ref.collection)
+		}
+	}
+	if err := expandParallel(ctx, x.opts.Concurrency, collections, func(col string) error {
+		var list []Document
+		var err error
+		if x.opts.Public {
+			list, err = x.c.getPublicDocuments(ctx, col)
+		} else {
+			list, err = x.c.getDocuments(ctx, col)
+		}
+		if err != nil {
+			return fmt.Errorf("list documents of collection %s: %w", col, err)
+		}
+		x.mu.Lock()
+		for i := range list {
+			x.docs[docRef{collection: col, uuid: list[i].UUID}] = &list[i]
+		}
+		x.listed[col] = true
+		x.mu.Unlock()
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(media) > 0 && !x.mediaListed {
+		list, err := x.c.listAllMedia(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("list media: %w", err)
+		}
+		for i := range list {
+			x.media[list[i].UUID] = &list[i]
+		}
+		x.mediaListed = true
+	}
+	docs = slices.DeleteFunc(docs, func(ref docRef) bool { return x.docs[ref] != nil })
+	media = slices.DeleteFunc(media, func(id string) bool { return x.media[id] != nil })
+
+	if err := expandParallel(ctx, x.opts.Concurrency, docs, func(ref docRef) error {
+		doc, err := x.c.findDocument(ctx, ref.collection, ref.uuid, x.opts.Public)
+		if err != nil {
+			return fmt.Errorf("get document %s of collection %s: %w", ref.uuid, ref.collection, err)
+		}
+		x.mu.Lock()
+		x.docs[ref] = doc
+		x.mu.Unlock()
+		return nil
+	}); err != nil {
+		return err
+	}
+	return expandParallel(ctx, x.opts.Concurrency, media, func(id string) error {
+		m, err := x.c.findMedia(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get media %s: %w", id, err)
+		}
+		x.mu.Lock()
+		x.media[id] = m
+		x.mu.Unlock()
+		return nil
+	})
+}
+
+// replace substitutes the loaded documents and media for the references in
+// the frontier and returns the expanded documents, whose own references
+// form the next level.
+func (x *expander) replace(frontier []expandNode) []expandNode {
+	var next []expandNode
+	for _, n := range frontier {
+		for _, f := range x.referenceFields(n.collection) {
+			value, ok := n.payload[f.Name]
+			if !ok {
+				continue
+			}
+			resolve := func(id string) interface{} {
+				if f.Type == FieldTypeMedia {
+					if m := x.media[id]; m != nil {
+						copied := *m
+						return &copied
+					}
+					return id
+				}
+				found := x.docs[docRef{collection: f.Collection, uuid: id}]
+				if found == nil || n.ancestors[id] {
+					return id
+				}
+				doc := *found
+				if p, ok := normalizeJSON(doc.Payload).(map[string]interface{}); ok {
+					doc.Payload = p
+					ancestors := maps.Clone(n.ancestors)
+					ancestors[id] = true
+					next = append(next, expandNode{collection: f.Collection, payload: p, ancestors: ancestors})
+				}
+				return &doc
+			}
+			switch v := value.(type) {
+			case string:
+				n.payload[f.Name] = resolve(v)
+			case []interface{}:
+				expanded := make([]interface{}, len(v))
+				for i, item := range v {
+					if id, ok := item.(string); ok {
+						expanded[i] = resolve(id)
+					} else {
+						expanded[i] = item
+					}
+				}
+				n.payload[f.Name] = expanded
+			case []string:
+				expanded := make([]interface{}, len(v))
+				for i, id := range v {
+					expanded[i] = resolve(id)
+				}
+				n.payload[f.Name] = expanded
+			}
+		}
+	}
+	return next
+}
+
+// referenceFields returns the fields of a collection that are expanded.
+func (x *expander) referenceFields(collection string) []CollectionField {
+	var fields []CollectionField
+	for _, f := range x.fields[collection] {
+		switch {
+		case f.Type == FieldTypeMedia && !x.opts.Public:
+		case f.Type == FieldTypeReference && f.Collection != "":
+		default:
+			continue
+		}
+		if len(x.opts.Fields) > 0 && !slices.Contains(x.opts.Fields, f.Name) {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// expandParallel calls fn for each key with at most concurrency calls in
+// flight, returning the first error.
+func expandParallel[T any](ctx context.Context, concurrency int, keys []T, fn func(T) error) error {
+	var once sync.Once
+	var firstErr error
+	runWorkers(ctx, sliceChan(ctx, keys), concurrency, func(key T) {
+		if err := fn(key); err != nil {
+			once.Do(func() { firstErr = err })
+		}
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// referencedUUIDs returns the UUIDs stored in a reference or media field.
+func referencedUUIDs(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []string:
+		return v
+	case []interface{}:
+		var ids []string
+		for _, item := range v {
+			if id, ok := item.(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	return nil
+}
+
+// findDocument fetches a document, through the public API if public is
+// set, returning nil without an error if it does not exist.
+func (c *Client) findDocument(ctx context.Context, collectionUUID, documentUUID string, public bool) (*Document, error) {
+	url := fmt.Sprintf("%s/api/v1/documents/%s/%s", c.BaseURL, collectionUUID, documentUUID)
+	if public {
+		url = fmt.Sprintf("%s/api/v1/documents/collection/%s/%s", c.BaseURL, collectionUUID, documentUUID)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !public {
+		req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var doc Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// findMedia fetches a media file, returning nil without an error if it
+// does not exist.
+func (c *Client) findMedia(ctx context.Context, mediaUUID string) (*Media, error) {
+	url := fmt.Sprintf("%s/api/v1/media/%s", c.BaseURL, mediaUUID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var media Media
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
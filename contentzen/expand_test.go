@@ -0,0 +1,86 @@
+package contentzen_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+	"github.com/contentzen-hub/sdk-go/contentzentest"
+)
+
+func TestExpandDocumentsBatched(t *testing.T) {
+	srv := contentzentest.NewServer()
+	defer srv.Close()
+	authors := srv.AddCollection(contentzen.Collection{
+		Name:   "authors",
+		Fields: []contentzen.CollectionField{{Name: "name", Type: contentzen.FieldTypeText}},
+	})
+	posts := srv.AddCollection(contentzen.Collection{
+		Name: "posts",
+		Fields: []contentzen.CollectionField{
+			{Name: "author", Type: contentzen.FieldTypeReference, Collection: authors.UUID},
+			{Name: "cover", Type: contentzen.FieldTypeMedia},
+		},
+	})
+	var authorUUIDs []string
+	for i := 0; i < 3; i++ {
+		a := srv.AddDocument(authors.UUID, contentzen.Document{Payload: map[string]interface{}{"name": fmt.Sprintf("author %d", i)}})
+		authorUUIDs = append(authorUUIDs, a.UUID)
+	}
+	cover := srv.AddMedia("cover.png", []byte("png"))
+	docs := make([]contentzen.Document, 6)
+	for i := range docs {
+		docs[i] = contentzen.Document{UUID: fmt.Sprintf("post-%d", i), Payload: map[string]interface{}{
+			"author": authorUUIDs[i%3],
+			"cover":  cover.UUID,
+		}}
+	}
+	docs[5].Payload["author"] = "missing"
+
+	c := srv.Client()
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	transport := c.HTTPClient.Transport
+	c.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requests[req.Method+" "+req.URL.Path]++
+		mu.Unlock()
+		return transport.RoundTrip(req)
+	})}
+
+	if err := c.ExpandDocuments(context.Background(), posts.UUID, docs, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range docs[:5] {
+		author, ok := doc.Payload["author"].(*contentzen.Document)
+		if !ok || author.UUID != authorUUIDs[i%3] {
+			t.Errorf("post %d: author = %#v", i, doc.Payload["author"])
+		}
+		if m, ok := doc.Payload["cover"].(*contentzen.Media); !ok || m.UUID != cover.UUID {
+			t.Errorf("post %d: cover = %#v", i, doc.Payload["cover"])
+		}
+	}
+	if got := docs[5].Payload["author"]; got != "missing" {
+		t.Errorf("missing author = %#v, want the UUID", got)
+	}
+
+	want := map[string]int{
+		"GET /api/v1/collections/" + posts.UUID + "/fields":  1,
+		"GET /api/v1/documents/" + authors.UUID:              1,
+		"GET /api/v1/documents/" + authors.UUID + "/missing": 1,
+		"GET /api/v1/media":                                  1,
+	}
+	for path, n := range requests {
+		if want[path] != n {
+			t.Errorf("%s requested %d times, want %d", path, n, want[path])
+		}
+	}
+	for path := range want {
+		if requests[path] == 0 {
+			t.Errorf("%s not requested", path)
+		}
+	}
+}
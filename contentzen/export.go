@@ -0,0 +1,652 @@
+package contentzen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/contentzen-hub/sdk-go/richtext"
+)
+
+// DefaultExportManifestName is the manifest file written by ExportSite in
+// the site directory, listing the files it wrote.
+const DefaultExportManifestName = ".contentzen-export.json"
+
+// DefaultExportTemplate renders a page as its front matter followed by its
+// body.
+const DefaultExportTemplate = "{{frontmatter .FrontMatter}}\n{{.Body}}"
+
+// ExportCollection configures how the documents of a collection are
+// exported.
+type ExportCollection struct {
+	UUID string
+	// Section is the directory under the content directory the documents
+	// are written to. Defaults to the collection's name.
+	Section string
+	// Template is a text/template rendering an ExportPage to the contents
+	// of its file. Defaults to DefaultExportTemplate. The frontmatter
+	// function formats a map as front matter in the collection's format.
+	Template string
+	// Format is the front matter format, FrontMatterYAML by default.
+	Format string
+	// BodyField is the field written as the page body. Defaults to a field
+	// named "body" or "content", or else the first markdown or rich-text
+	// field.
+	BodyField string
+	// Slug returns the file name of a document, without extension.
+	// Defaults to the document's "slug" field, or its UUID.
+	Slug func(doc *Document) string
+}
+
+// ExportOptions configures ExportSite.
+type ExportOptions struct {
+	Collections []ExportCollection
+	// ContentDir and StaticDir are the directories, relative to the site
+	// directory, pages and media are written to. They default to "content"
+	// and "static".
+	ContentDir string
+	StaticDir  string
+	// MediaPath is the directory under StaticDir media are downloaded to,
+	// and the URL path they are served from. Defaults to "media".
+	MediaPath string
+	// DefaultLang is the language of pages written without a language
+	// suffix. If set, documents in other languages are written as
+	// "slug.<lang>.md".
+	DefaultLang string
+	// Prune removes the files written by a previous export that no longer
+	// belong to a published document. It is skipped if ctx is cancelled.
+	Prune bool
+	// Concurrency is the number of media downloaded in parallel. Defaults
+	// to 4.
+	Concurrency int
+}
+
+// ExportPage is the data a collection's template is executed with.
+type ExportPage struct {
+	Document *Document
+	// Section is the collection's section.
+	Section string
+	// Path is the slash-separated path of the page's file, relative to the
+	// content directory, and URL the path it is served from.
+	Path string
+	URL  string
+	// FrontMatter holds the document's fields other than the body, with
+	// rich text rendered to Markdown and media replaced with their local
+	// URLs, and its "uuid" and "lang". Numbers without a fraction are
+	// int64, as JSON does not tell 1 from 1.0.
+	FrontMatter map[string]interface{}
+	// Body is the Markdown body of the page.
+	Body string
+}
+
+// ExportReport lists the files affected by ExportSite. Paths are
+// slash-separated and relative to the site directory.
+type ExportReport struct {
+	Written   []string
+	Unchanged []string
+	// Downloaded lists the media files downloaded into the static
+	// directory.
+	Downloaded []string
+	// Removed lists the files of a previous export deleted by Prune.
+	Removed []string
+	// Errors holds per-document and per-media failures; the other files
+	// are still exported.
+	Errors []error
+}
+
+// ExportSite writes the published documents of the configured collections
+// as a Hugo-style content tree under dir (requires API token).
+//
+// Each document from GetPublicDocuments is written to
+// <ContentDir>/<section>/<slug>.md by its collection's template. Rich-text
+// fields are rendered to Markdown, links between exported documents point
+// to their pages, and referenced media are downloaded to
+// <StaticDir>/<MediaPath>/<uuid>/<filename> and linked locally. The output
+// depends only on the content: front matter keys are sorted, and files and
+// media whose contents did not change are not rewritten, so that their
+// modification times stay usable for incremental builds.
+func (c *Client) ExportSite(ctx context.Context, dir string, opts *ExportOptions) (*ExportReport, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	o := ExportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ContentDir == "" {
+		o.ContentDir = "content"
+	}
+	if o.StaticDir == "" {
+		o.StaticDir = "static"
+	}
+	if o.MediaPath == "" {
+		o.MediaPath = "media"
+	}
+	o.MediaPath = strings.Trim(o.MediaPath, "/")
+	o.Collections = slices.Clone(o.Collections)
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+
+	media, err := c.listAllMedia(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	x := &exporter{
+		c:       c,
+		opts:    o,
+		dir:     dir,
+		byUUID:  make(map[string]*Media, len(media)),
+		byURL:   make(map[string]*Media, len(media)),
+		pages:   make(map[string]*exportPage),
+		used:    make(map[string]*Media),
+		written: make(map[string]bool),
+		report:  &ExportReport{},
+	}
+	for i := range media {
+		if !isPathElement(media[i].UUID) {
+			x.fail(fmt.Errorf("export media: invalid UUID %q", media[i].UUID))
+			continue
+		}
+		x.byUUID[media[i].UUID] = &media[i]
+		x.byURL[media[i].URL] = &media[i]
+	}
+
+	// Lay out every page first, so that links between documents can be
+	// resolved while rendering.
+	var pages []*exportPage
+	for i := range o.Collections {
+		ps, err := x.layout(ctx, &o.Collections[i])
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, ps...)
+	}
+	for _, p := range pages {
+		if err := ctx.Err(); err != nil {
+			return x.report, err
+		}
+		if err := x.render(p); err != nil {
+			x.fail(fmt.Errorf("export document %s: %w", p.doc.UUID, err))
+			// Keep the page of the previous export.
+			x.written[path.Join(filepath.ToSlash(o.ContentDir), p.path)] = true
+		}
+	}
+	x.download(ctx)
+
+	manifestPath := filepath.Join(dir, DefaultExportManifestName)
+	previous, err := loadExportManifest(manifestPath)
+	if err != nil {
+		return x.report, err
+	}
+	// A cancelled export has not written every page, so pruning would
+	// remove files that still belong to published documents.
+	if o.Prune && ctx.Err() == nil {
+		for _, rel := range previous {
+			if x.written[rel] {
+				continue
+			}
+			target := filepath.Join(dir, filepath.FromSlash(rel))
+			if !filepath.IsLocal(filepath.FromSlash(rel)) || !withinDir(dir, filepath.Dir(target)) {
+				x.fail(fmt.Errorf("prune: manifest entry %q is outside %s", rel, dir))
+				continue
+			}
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				x.fail(err)
+				continue
+			}
+			x.report.Removed = append(x.report.Removed, rel)
+		}
+	} else {
+		for _, rel := range previous {
+			x.written[rel] = true
+		}
+	}
+	if err := saveExportManifest(manifestPath, x.written); err != nil {
+		return x.report, err
+	}
+
+	sort.Strings(x.report.Written)
+	sort.Strings(x.report.Unchanged)
+	sort.Strings(x.report.Downloaded)
+	return x.report, ctx.Err()
+}
+
+type exporter struct {
+	c      *Client
+	opts   ExportOptions
+	dir    string
+	byUUID map[string]*Media
+	byURL  map[string]*Media
+	// pages maps "collection/document" to the document's page.
+	pages map[string]*exportPage
+
+	mu sync.Mutex
+	// used holds the media referenced by the exported pages.
+	used map[string]*Media
+	// written holds the files belonging to this export.
+	written map[string]bool
+	report  *ExportReport
+}
+
+// exportPage is a document placed in the content tree.
+type exportPage struct {
+	col    *ExportCollection
+	fields []CollectionField
+	body   *CollectionField
+	tmpl   *template.Template
+	doc    Document
+	path   string
+	url    string
+}
+
+func (x *exporter) fail(err error) {
+	x.mu.Lock()
+	x.report.Errors = append(x.report.Errors, err)
+	x.mu.Unlock()
+}
+
+// layout fetches the published documents of a collection and assigns
+// each a path. Documents whose path is taken are reported as errors.
+func (x *exporter) layout(ctx context.Context, col *ExportCollection) ([]*exportPage, error) {
+	section := col.Section
+	if section == "" {
+		info, err := x.c.getCollection(ctx, col.UUID)
+		if err != nil {
+			return nil, fmt.Errorf("get collection %s: %w", col.UUID, err)
+		}
+		section = info.Name
+	}
+	col.Section = strings.Trim(path.Clean("/"+section), "/")
+	fields, err := x.c.getCollectionFields(ctx, col.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("get fields of collection %s: %w", col.UUID, err)
+	}
+	body := exportBodyField(fields, col.BodyField)
+	if body == nil && col.BodyField != "" {
+		return nil, fmt.Errorf("collection %s has no field %s", col.UUID, col.BodyField)
+	}
+	format := col.Format
+	tmplText := col.Template
+	if tmplText == "" {
+		tmplText = DefaultExportTemplate
+	}
+	tmpl, err := template.New(col.Section).Funcs(template.FuncMap{
+		"frontmatter": func(fm map[string]interface{}) (string, error) {
+			b, err := FormatFrontMatter(fm, format)
+			return string(b), err
+		},
+	}).Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf("template of collection %s: %w", col.UUID, err)
+	}
+	docs, err := x.c.getPublicDocuments(ctx, col.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("get documents of collection %s: %w", col.UUID, err)
+	}
+
+	pages := make([]*exportPage, 0, len(docs))
+	for _, doc := range docs {
+		slug := exportSlug(col, &doc)
+		if !isPathElement(slug) {
+			x.fail(fmt.Errorf("export document %s: invalid file name %q", doc.UUID, slug))
+			continue
+		}
+		name, url := slug, "/"+col.Section+"/"+slug+"/"
+		if x.opts.DefaultLang != "" && doc.Lang != "" && !strings.EqualFold(doc.Lang, x.opts.DefaultLang) {
+			if !isPathElement(doc.Lang) {
+				x.fail(fmt.Errorf("export document %s: invalid language %q", doc.UUID, doc.Lang))
+				continue
+			}
+			name += "." + doc.Lang
+			url = "/" + doc.Lang + url
+		}
+		pages = append(pages, &exportPage{
+			col:    col,
+			fields: fields,
+			body:   body,
+			tmpl:   tmpl,
+			doc:    doc,
+			path:   path.Join(col.Section, name+".md"),
+			url:    url,
+		})
+	}
+	// Resolve path collisions in a stable order.
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].path != pages[j].path {
+			return pages[i].path < pages[j].path
+		}
+		return pages[i].doc.UUID < pages[j].doc.UUID
+	})
+	kept := pages[:0]
+	for i, p := range pages {
+		if i > 0 && p.path == pages[i-1].path {
+			x.fail(fmt.Errorf("export document %s: %s is already used by document %s", p.doc.UUID, p.path, pages[i-1].doc.UUID))
+			continue
+		}
+		// Links point to the first section a document is exported to.
+		if key := col.UUID + "/" + p.doc.UUID; x.pages[key] == nil {
+			x.pages[key] = p
+		}
+		kept = append(kept, p)
+	}
+	return kept, nil
+}
+
+// exportBodyField returns the field written as the page body, or nil.
+func exportBodyField(fields []CollectionField, name string) *CollectionField {
+	for i, f := range fields {
+		if name != "" && f.Name == name || name == "" && (strings.EqualFold(f.Name, "body") || strings.EqualFold(f.Name, "content")) {
+			return &fields[i]
+		}
+	}
+	if name != "" {
+		return nil
+	}
+	for i, f := range fields {
+		if f.Type == FieldTypeMarkdown || f.Type == FieldTypeRichText {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// exportSlug returns the file name of a document, made safe to use as a
+// single path element.
+func exportSlug(col *ExportCollection, doc *Document) string {
+	var slug string
+	if col.Slug != nil {
+		slug = col.Slug(doc)
+	} else if s, ok := doc.Payload["slug"].(string); ok {
+		slug = s
+	}
+	slug = strings.Trim(strings.NewReplacer("/", "-", "\\", "-").Replace(strings.TrimSpace(slug)), ".")
+	if slug == "" {
+		return doc.UUID
+	}
+	return slug
+}
+
+// jsonIntegers returns v with the whole float64 numbers decoded from JSON
+// replaced by int64, so that front matter writes them as integers.
+func jsonIntegers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = jsonIntegers(val)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, val := range v {
+			list[i] = jsonIntegers(val)
+		}
+		return list
+	}
+	return v
+}
+
+// isPathElement reports whether s can be used as a single path element
+// without leaving its directory.
+func isPathElement(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, "/\\\x00")
+}
+
+// render renders a page and writes it if its contents changed.
+func (x *exporter) render(p *exportPage) error {
+	page := &ExportPage{
+		Document:    &p.doc,
+		Section:     p.col.Section,
+		Path:        p.path,
+		URL:         p.url,
+		FrontMatter: make(map[string]interface{}),
+	}
+	types := make(map[string]string, len(p.fields))
+	for _, f := range p.fields {
+		types[f.Name] = f.Type
+	}
+	for key, value := range p.doc.Payload {
+		v, err := x.value(types[key], value)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", key, err)
+		}
+		if p.body != nil && key == p.body.Name {
+			if s, ok := v.(string); ok {
+				page.Body = s
+			}
+			continue
+		}
+		page.FrontMatter[key] = jsonIntegers(v)
+	}
+	if page.Body != "" && !strings.HasSuffix(page.Body, "\n") {
+		page.Body += "\n"
+	}
+	if _, ok := page.FrontMatter["uuid"]; !ok {
+		page.FrontMatter["uuid"] = p.doc.UUID
+	}
+	if _, ok := page.FrontMatter["lang"]; !ok && p.doc.Lang != "" {
+		page.FrontMatter["lang"] = p.doc.Lang
+	}
+
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, page); err != nil {
+		return err
+	}
+	rel := path.Join(filepath.ToSlash(x.opts.ContentDir), p.path)
+	changed, err := writeIfChanged(filepath.Join(x.dir, filepath.FromSlash(rel)), buf.Bytes())
+	if err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.written[rel] = true
+	if changed {
+		x.report.Written = append(x.report.Written, rel)
+	} else {
+		x.report.Unchanged = append(x.report.Unchanged, rel)
+	}
+	return nil
+}
+
+// value converts a field value for the front matter or body.
+func (x *exporter) value(fieldType string, value interface{}) (interface{}, error) {
+	switch fieldType {
+	case FieldTypeRichText:
+		if value == nil {
+			return nil, nil
+		}
+		n, err := richtext.ParseValue(value)
+		if err != nil {
+			return nil, err
+		}
+		r := &richtext.MarkdownRenderer{
+			Media: func(n *richtext.Node) (string, error) {
+				m := x.byUUID[n.Attr("uuid")]
+				if m == nil {
+					return "", nil
+				}
+				alt := n.Attr("alt")
+				if alt == "" {
+					alt = m.AltText
+				}
+				return "![" + escapeMarkdownText(alt) + "](" + x.mediaURL(m) + ")", nil
+			},
+			DocumentURL: func(collectionUUID, documentUUID string) (string, error) {
+				if p := x.pages[collectionUUID+"/"+documentUUID]; p != nil {
+					return p.url, nil
+				}
+				return "", nil
+			},
+		}
+		return r.Render(n)
+	case FieldTypeMarkdown:
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		return rewriteMarkdownLinks(s, func(target string) (string, error) {
+			if m := x.byURL[target]; m != nil {
+				return x.mediaURL(m), nil
+			}
+			return target, nil
+		})
+	case FieldTypeMedia:
+		switch v := value.(type) {
+		case string:
+			if m := x.byUUID[v]; m != nil {
+				return x.mediaURL(m), nil
+			}
+		case []interface{}:
+			urls := make([]interface{}, len(v))
+			for i, item := range v {
+				urls[i] = item
+				if id, ok := item.(string); ok {
+					if m := x.byUUID[id]; m != nil {
+						urls[i] = x.mediaURL(m)
+					}
+				}
+			}
+			return urls, nil
+		}
+	}
+	return value, nil
+}
+
+// mediaURL records that a media file is used and returns the URL it is
+// served from once downloaded.
+func (x *exporter) mediaURL(m *Media) string {
+	x.mu.Lock()
+	x.used[m.UUID] = m
+	x.mu.Unlock()
+	segments := strings.Split(x.mediaRel(m), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// mediaRel returns the path of a media file relative to the static
+// directory.
+func (x *exporter) mediaRel(m *Media) string {
+	name := path.Base("/" + strings.ReplaceAll(m.Filename, "\\", "/"))
+	if name == "/" || name == "." || name == ".." {
+		name = m.UUID
+	}
+	return path.Join(x.opts.MediaPath, m.UUID, name)
+}
+
+// download downloads the used media whose local copy is missing or
+// differs.
+func (x *exporter) download(ctx context.Context) {
+	ids := make([]string, 0, len(x.used))
+	for id := range x.used {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	type download struct {
+		media *Media
+		rel   string
+		dest  string
+	}
+	var downloads []download
+	for _, id := range ids {
+		m := x.used[id]
+		rel := path.Join(filepath.ToSlash(x.opts.StaticDir), x.mediaRel(m))
+		x.written[rel] = true
+		dest := filepath.Join(x.dir, filepath.FromSlash(rel))
+		if info, err := os.Stat(dest); err == nil && info.Size() == m.Size {
+			if m.Checksum == "" {
+				continue
+			}
+			if sum, _, err := hashFile(dest); err == nil && sum == m.Checksum {
+				continue
+			}
+		}
+		downloads = append(downloads, download{media: m, rel: rel, dest: dest})
+	}
+	runWorkers(ctx, sliceChan(ctx, downloads), x.opts.Concurrency, func(d download) {
+		if err := os.MkdirAll(filepath.Dir(d.dest), 0o755); err != nil {
+			x.fail(err)
+			return
+		}
+		if err := x.c.DownloadMediaFile(ctx, d.media.UUID, d.dest, &DownloadOptions{Checksum: d.media.Checksum}); err != nil {
+			x.fail(fmt.Errorf("download media %s: %w", d.media.UUID, err))
+			return
+		}
+		x.mu.Lock()
+		x.report.Downloaded = append(x.report.Downloaded, d.rel)
+		x.mu.Unlock()
+	})
+}
+
+// writeIfChanged writes data to p unless p already holds it, reporting
+// whether it wrote.
+func writeIfChanged(p string, data []byte) (bool, error) {
+	if existing, err := os.ReadFile(p); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return false, err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp, p)
+}
+
+func escapeMarkdownText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
+
+// exportManifest lists the files written by ExportSite.
+type exportManifest struct {
+	Files []string `json:"files"`
+}
+
+func loadExportManifest(p string) ([]string, error) {
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m exportManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("decode manifest %s: %w", p, err)
+	}
+	return m.Files, nil
+}
+
+func saveExportManifest(p string, files map[string]bool) error {
+	m := exportManifest{Files: make([]string, 0, len(files))}
+	for rel := range files {
+		m.Files = append(m.Files, rel)
+	}
+	sort.Strings(m.Files)
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writeIfChanged(p, append(b, '\n'))
+	return err
+}
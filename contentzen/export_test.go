@@ -0,0 +1,236 @@
+package contentzen_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+	"github.com/contentzen-hub/sdk-go/contentzentest"
+)
+
+type exportTest struct {
+	srv   *contentzentest.Server
+	c     *contentzen.Client
+	col   string
+	media contentzen.Media
+	// world is the UUID of the second published document.
+	world string
+}
+
+func newExportTest(t *testing.T) *exportTest {
+	t.Helper()
+	srv := contentzentest.NewServer()
+	t.Cleanup(srv.Close)
+	col := srv.AddCollection(contentzen.Collection{
+		Name:     "posts",
+		IsPublic: true,
+		Fields: []contentzen.CollectionField{
+			{Name: "title", Type: contentzen.FieldTypeText},
+			{Name: "slug", Type: contentzen.FieldTypeText},
+			{Name: "body", Type: contentzen.FieldTypeMarkdown},
+			{Name: "cover", Type: contentzen.FieldTypeMedia},
+		},
+	})
+	media := srv.AddMedia("cover.png", []byte("not really a png"))
+	srv.AddDocument(col.UUID, contentzen.Document{
+		State: string(contentzen.StatePublished),
+		Payload: map[string]interface{}{
+			"title":  "Hello",
+			"slug":   "hello",
+			"body":   "Hi *there*.",
+			"cover":  media.UUID,
+			"tags":   []interface{}{"a", "b"},
+			"rating": 4.0,
+			"author": map[string]interface{}{"name": "Ann", "email": "ann@example.com"},
+		},
+	})
+	world := srv.AddDocument(col.UUID, contentzen.Document{
+		State:   string(contentzen.StatePublished),
+		Payload: map[string]interface{}{"title": "World", "slug": "world", "body": "Second."},
+	})
+	srv.AddDocument(col.UUID, contentzen.Document{
+		State:   string(contentzen.StateDraft),
+		Payload: map[string]interface{}{"title": "Draft", "slug": "draft"},
+	})
+	return &exportTest{srv: srv, c: srv.Client(), col: col.UUID, media: media, world: world.UUID}
+}
+
+func (e *exportTest) export(t *testing.T, ctx context.Context, dir string, prune bool) (*contentzen.ExportReport, error) {
+	t.Helper()
+	return e.c.ExportSite(ctx, dir, &contentzen.ExportOptions{
+		Collections: []contentzen.ExportCollection{{UUID: e.col}},
+		Prune:       prune,
+	})
+}
+
+// readTree returns the contents of the files under dir by slash-separated
+// relative path.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestExportSiteDeterministic(t *testing.T) {
+	e := newExportTest(t)
+	first, second := t.TempDir(), t.TempDir()
+	for _, dir := range []string{first, second} {
+		report, err := e.export(t, context.Background(), dir, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Errors) > 0 {
+			t.Fatalf("errors: %v", report.Errors)
+		}
+	}
+
+	a, b := readTree(t, first), readTree(t, second)
+	mediaRel := "static/media/" + e.media.UUID + "/cover.png"
+	for _, rel := range []string{"content/posts/hello.md", "content/posts/world.md", mediaRel, contentzen.DefaultExportManifestName} {
+		if _, ok := a[rel]; !ok {
+			t.Errorf("%s not exported", rel)
+		}
+	}
+	if _, ok := a["content/posts/draft.md"]; ok {
+		t.Error("draft document exported")
+	}
+	for rel, data := range a {
+		if b[rel] != data {
+			t.Errorf("%s differs between exports:\n%s\n---\n%s", rel, data, b[rel])
+		}
+	}
+	if len(a) != len(b) {
+		t.Errorf("exports have %d and %d files", len(a), len(b))
+	}
+
+	hello := a["content/posts/hello.md"]
+	for _, want := range []string{"title: Hello", "rating: 4\n", "cover: /media/" + e.media.UUID + "/cover.png", "Hi *there*."} {
+		if !strings.Contains(hello, want) {
+			t.Errorf("hello.md does not contain %q:\n%s", want, hello)
+		}
+	}
+	if strings.Index(hello, "author:") > strings.Index(hello, "title:") {
+		t.Errorf("front matter keys are not sorted:\n%s", hello)
+	}
+}
+
+func TestExportSiteUnchanged(t *testing.T) {
+	e := newExportTest(t)
+	dir := t.TempDir()
+	if _, err := e.export(t, context.Background(), dir, false); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	page := filepath.Join(dir, "content", "posts", "hello.md")
+	if err := os.Chtimes(page, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := e.export(t, context.Background(), dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Written) > 0 || len(report.Downloaded) > 0 {
+		t.Errorf("written %v, downloaded %v, want nothing", report.Written, report.Downloaded)
+	}
+	if want := []string{"content/posts/hello.md", "content/posts/world.md"}; !slices.Equal(report.Unchanged, want) {
+		t.Errorf("unchanged %v, want %v", report.Unchanged, want)
+	}
+	info, err := os.Stat(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Errorf("hello.md modified at %v, want %v", info.ModTime(), old)
+	}
+}
+
+func TestExportSitePrune(t *testing.T) {
+	e := newExportTest(t)
+	dir := t.TempDir()
+	if _, err := e.export(t, context.Background(), dir, true); err != nil {
+		t.Fatal(err)
+	}
+	own := filepath.Join(dir, "content", "posts", "mine.md")
+	if err := os.WriteFile(own, []byte("kept"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.c.DeleteDocument(e.col, e.world); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := e.export(t, context.Background(), dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"content/posts/world.md"}; !slices.Equal(report.Removed, want) {
+		t.Errorf("removed %v, want %v", report.Removed, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "content", "posts", "world.md")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("world.md not removed: %v", err)
+	}
+	if _, err := os.Stat(own); err != nil {
+		t.Errorf("file outside the manifest removed: %v", err)
+	}
+}
+
+func TestExportSiteCancelSkipsPrune(t *testing.T) {
+	e := newExportTest(t)
+	dir := t.TempDir()
+	if _, err := e.export(t, context.Background(), dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "static")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.c.DeleteDocument(e.col, e.world); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancel while the media file is downloaded, after every page was
+	// rendered.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transport := e.c.HTTPClient.Transport
+	e.c.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/api/v1/media" && strings.Contains(req.URL.Path, e.media.UUID) {
+			cancel()
+			return nil, ctx.Err()
+		}
+		return transport.RoundTrip(req)
+	})}
+
+	report, err := e.export(t, ctx, dir, true)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ExportSite() error = %v, want context.Canceled", err)
+	}
+	if len(report.Removed) > 0 {
+		t.Errorf("removed %v after cancellation", report.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "content", "posts", "world.md")); err != nil {
+		t.Errorf("world.md pruned after cancellation: %v", err)
+	}
+}
@@ -0,0 +1,1211 @@
+package contentzen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Front matter formats returned by ParseFrontMatter.
+const (
+	FrontMatterYAML = "yaml"
+	FrontMatterTOML = "toml"
+	FrontMatterJSON = "json"
+)
+
+// ErrFrontMatter is returned for front matter that cannot be parsed.
+var ErrFrontMatter = errors.New("invalid front matter")
+
+// ParseFrontMatter splits a Markdown file into its front matter and body.
+// YAML front matter is delimited by "---" lines, TOML front matter by
+// "+++" lines, and JSON front matter is an object at the start of the
+// file. A file without front matter yields a nil map, the whole file as
+// body, and format "".
+//
+// The subset of YAML and TOML used by static site generators is
+// supported: scalars, quoted and block strings, lists, nested mappings
+// and tables, and inline arrays and tables. Dates are returned as strings.
+func ParseFrontMatter(data []byte) (fm map[string]interface{}, body []byte, format string, err error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	first, rest, _ := bytes.Cut(data, []byte("\n"))
+	switch strings.TrimRight(string(first), " \t\r") {
+	case "---":
+		src, body, ok := cutFrontMatter(rest, "---", "...")
+		if !ok {
+			return nil, nil, "", fmt.Errorf("%w: missing closing ---", ErrFrontMatter)
+		}
+		fm, err = parseYAML(src)
+		return fm, body, FrontMatterYAML, err
+	case "+++":
+		src, body, ok := cutFrontMatter(rest, "+++")
+		if !ok {
+			return nil, nil, "", fmt.Errorf("%w: missing closing +++", ErrFrontMatter)
+		}
+		fm, err = parseTOML(src)
+		return fm, body, FrontMatterTOML, err
+	}
+	if bytes.HasPrefix(data, []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if err := dec.Decode(&fm); err != nil {
+			return nil, nil, "", fmt.Errorf("%w: %v", ErrFrontMatter, err)
+		}
+		return fm, bytes.TrimLeft(data[dec.InputOffset():], "\r\n"), FrontMatterJSON, nil
+	}
+	return nil, data, "", nil
+}
+
+// cutFrontMatter splits data at the first line equal to one of delims.
+func cutFrontMatter(data []byte, delims ...string) (string, []byte, bool) {
+	for off := 0; off < len(data); {
+		end := bytes.IndexByte(data[off:], '\n')
+		next := len(data)
+		if end >= 0 {
+			next = off + end + 1
+		}
+		line := strings.TrimRight(string(data[off:next]), " \t\r\n")
+		for _, d := range delims {
+			if line == d {
+				return string(data[:off]), bytes.TrimLeft(data[next:], "\r\n"), true
+			}
+		}
+		off = next
+	}
+	return "", nil, false
+}
+
+func frontMatterError(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: line %d: %s", ErrFrontMatter, line, fmt.Sprintf(format, args...))
+}
+
+// yamlParser parses YAML by indentation, one line at a time.
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+func parseYAML(src string) (map[string]interface{}, error) {
+	p := &yamlParser{lines: strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")}
+	indent, _, ok := p.peek()
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+	m, err := p.mapping(indent)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, ok := p.peek(); ok {
+		return nil, frontMatterError(p.pos+2, "unexpected indentation")
+	}
+	return m, nil
+}
+
+// peek returns the next line that is neither blank nor a comment, without
+// consuming it.
+func (p *yamlParser) peek() (indent int, text string, ok bool) {
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		text := strings.TrimSpace(line)
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		return len(line) - len(strings.TrimLeft(line, " ")), text, true
+	}
+	return 0, "", false
+}
+
+func (p *yamlParser) node(indent int) (interface{}, error) {
+	if _, text, _ := p.peek(); isYAMLListItem(text) {
+		return p.list(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for {
+		ind, text, ok := p.peek()
+		if !ok || ind < indent || ind == indent && isYAMLListItem(text) {
+			return m, nil
+		}
+		if ind > indent {
+			return nil, frontMatterError(p.pos+2, "unexpected indentation")
+		}
+		key, rest, ok := splitYAMLKey(text)
+		if !ok {
+			return nil, frontMatterError(p.pos+2, "expected key: value")
+		}
+		p.pos++
+		v, err := p.value(rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+}
+
+func (p *yamlParser) list(indent int) ([]interface{}, error) {
+	list := []interface{}{}
+	for {
+		ind, text, ok := p.peek()
+		if !ok || ind != indent || !isYAMLListItem(text) {
+			return list, nil
+		}
+		item := strings.TrimLeft(text[1:], " ")
+		if _, _, isKey := splitYAMLKey(item); isKey || isYAMLListItem(item) {
+			// Parse the rest of the line as the first line of a nested
+			// node indented to where the item starts.
+			itemIndent := indent + len(text) - len(item)
+			p.lines[p.pos] = strings.Repeat(" ", itemIndent) + item
+			v, err := p.node(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			continue
+		}
+		p.pos++
+		v, err := p.value(item, indent)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+}
+
+// value parses the value after a key or list marker found on a line
+// indented by indent; nested nodes and block scalars follow on the next
+// lines.
+func (p *yamlParser) value(rest string, indent int) (interface{}, error) {
+	line := p.pos + 1
+	switch {
+	case rest == "" || rest[0] == '#':
+		ind, text, ok := p.peek()
+		if !ok || ind < indent || ind == indent && !isYAMLListItem(text) {
+			return nil, nil
+		}
+		return p.node(ind)
+	case rest[0] == '|' || rest[0] == '>':
+		return p.blockScalar(rest, indent), nil
+	case rest[0] == '[' || rest[0] == '{':
+		src := rest
+		for !flowBalanced(src) && p.pos < len(p.lines) {
+			src += "\n" + p.lines[p.pos]
+			p.pos++
+		}
+		f := &flowParser{s: src, sep: ':', scalar: yamlScalar}
+		v, err := f.parse()
+		if err != nil {
+			return nil, frontMatterError(line, "%v", err)
+		}
+		return v, nil
+	case rest[0] == '"' || rest[0] == '\'':
+		s, after, err := quotedString(rest)
+		if err != nil {
+			return nil, frontMatterError(line, "%v", err)
+		}
+		if after = strings.TrimSpace(after); after != "" && after[0] != '#' {
+			return nil, frontMatterError(line, "unexpected %q after string", after)
+		}
+		return s, nil
+	}
+	if i := strings.Index(rest, " #"); i >= 0 {
+		rest = rest[:i]
+	}
+	return yamlScalar(strings.TrimSpace(rest))
+}
+
+// blockScalar reads a literal (|) or folded (>) block scalar.
+func (p *yamlParser) blockScalar(header string, indent int) string {
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := strings.TrimRight(p.lines[p.pos], " \t\r")
+		if line == "" {
+			lines = append(lines, "")
+			continue
+		}
+		ind := len(line) - len(strings.TrimLeft(line, " "))
+		if ind <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = ind
+		}
+		if ind < blockIndent {
+			break
+		}
+		lines = append(lines, line[blockIndent:])
+	}
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	trailing := len(lines) - end
+	lines = lines[:end]
+
+	var s string
+	if header[0] == '|' {
+		s = strings.Join(lines, "\n")
+	} else {
+		var sb strings.Builder
+		for i, l := range lines {
+			switch {
+			case i == 0, lines[i-1] == "" && l != "":
+			case l == "":
+				sb.WriteString("\n")
+			default:
+				sb.WriteString(" ")
+			}
+			sb.WriteString(l)
+		}
+		s = sb.String()
+	}
+	switch {
+	case strings.Contains(header, "-") || s == "":
+	case strings.Contains(header, "+"):
+		s += strings.Repeat("\n", trailing+1)
+	default:
+		s += "\n"
+	}
+	return s
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" into its key and value.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if text == "" || strings.ContainsRune("[{#", rune(text[0])) {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		k, after, err := quotedString(text)
+		if err != nil {
+			return "", "", false
+		}
+		after = strings.TrimLeft(after, " ")
+		if after != ":" && !strings.HasPrefix(after, ": ") {
+			return "", "", false
+		}
+		return k, strings.TrimSpace(after[1:]), true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			key = strings.TrimSpace(text[:i])
+			if key == "" || key == "-" || strings.HasPrefix(key, "- ") {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+		if text[i] == ' ' && i+1 < len(text) && text[i+1] == '#' {
+			break
+		}
+	}
+	return "", "", false
+}
+
+// yamlScalar converts a plain YAML scalar.
+func yamlScalar(s string) (interface{}, error) {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if strings.Trim(s, "0123456789+-.eE") == "" {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+	return s, nil
+}
+
+// parseTOML parses a TOML document.
+func parseTOML(src string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 2
+		text := strings.TrimSpace(lines[i])
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		if text[0] == '[' {
+			array := strings.HasPrefix(text, "[[")
+			closing := "]"
+			if array {
+				closing = "]]"
+			}
+			end := strings.Index(text, closing)
+			if end < 0 {
+				return nil, frontMatterError(num, "unterminated table header")
+			}
+			start := len(closing)
+			path, err := tomlKeyPath(text[start:end])
+			if err != nil {
+				return nil, frontMatterError(num, "%v", err)
+			}
+			if rest := strings.TrimSpace(text[end+len(closing):]); rest != "" && rest[0] != '#' {
+				return nil, frontMatterError(num, "unexpected %q after table header", rest)
+			}
+			parent, err := tomlTable(root, path[:len(path)-1])
+			if err != nil {
+				return nil, frontMatterError(num, "%v", err)
+			}
+			last := path[len(path)-1]
+			if array {
+				var tables []interface{}
+				if existing, ok := parent[last]; ok {
+					if tables, ok = existing.([]interface{}); !ok {
+						return nil, frontMatterError(num, "%s is not an array of tables", last)
+					}
+				}
+				table = make(map[string]interface{})
+				parent[last] = append(tables, table)
+			} else if table, err = tomlTable(parent, []string{last}); err != nil {
+				return nil, frontMatterError(num, "%v", err)
+			}
+			continue
+		}
+
+		eq := indexOutsideQuotes(text, '=')
+		if eq < 0 {
+			return nil, frontMatterError(num, "expected key = value")
+		}
+		path, err := tomlKeyPath(text[:eq])
+		if err != nil {
+			return nil, frontMatterError(num, "%v", err)
+		}
+		src := strings.TrimSpace(text[eq+1:])
+		multiline := strings.HasPrefix(src, `"""`) || strings.HasPrefix(src, "'''")
+		for (multiline && openMultiline(src) || !multiline && !flowBalanced(src)) && i+1 < len(lines) {
+			i++
+			src += "\n" + lines[i]
+		}
+		var v interface{}
+		if multiline {
+			v, err = tomlMultiline(src)
+		} else {
+			v, err = (&flowParser{s: src, sep: '=', scalar: tomlScalar}).parse()
+		}
+		if err != nil {
+			return nil, frontMatterError(num, "%v", err)
+		}
+		parent, err := tomlTable(table, path[:len(path)-1])
+		if err != nil {
+			return nil, frontMatterError(num, "%v", err)
+		}
+		last := path[len(path)-1]
+		if _, exists := parent[last]; exists {
+			return nil, frontMatterError(num, "duplicate key %s", last)
+		}
+		parent[last] = v
+	}
+	return root, nil
+}
+
+// tomlTable returns the table at path below t, creating missing tables.
+// A path element naming an array of tables refers to its last table.
+func tomlTable(t map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, key := range path {
+		switch v := t[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			t[key] = next
+			t = next
+		case map[string]interface{}:
+			t = v
+		case []interface{}:
+			if len(v) == 0 {
+				return nil, fmt.Errorf("%s is not a table", key)
+			}
+			last, ok := v[len(v)-1].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not a table", key)
+			}
+			t = last
+		default:
+			return nil, fmt.Errorf("%s is not a table", key)
+		}
+	}
+	return t, nil
+}
+
+// tomlKeyPath splits a dotted TOML key.
+func tomlKeyPath(s string) ([]string, error) {
+	var path []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("empty key")
+		}
+		var key string
+		if s[0] == '"' || s[0] == '\'' {
+			k, rest, err := quotedString(s)
+			if err != nil {
+				return nil, err
+			}
+			key, s = k, strings.TrimSpace(rest)
+		} else {
+			end := strings.IndexByte(s, '.')
+			if end < 0 {
+				end = len(s)
+			}
+			key, s = strings.TrimSpace(s[:end]), s[end:]
+			if key == "" || strings.ContainsAny(key, " \t") {
+				return nil, fmt.Errorf("invalid key %q", key)
+			}
+		}
+		path = append(path, key)
+		if s == "" {
+			return path, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("invalid key %q", s)
+		}
+		s = s[1:]
+	}
+}
+
+// tomlMultiline parses a multi-line basic or literal string.
+func tomlMultiline(src string) (string, error) {
+	delim := src[:3]
+	body := strings.TrimPrefix(src[3:], "\n")
+	end := strings.Index(body, delim)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	if rest := strings.TrimSpace(body[end+3:]); rest != "" && rest[0] != '#' {
+		return "", fmt.Errorf("unexpected %q after string", rest)
+	}
+	body = body[:end]
+	if delim == "'''" {
+		return body, nil
+	}
+	var sb strings.Builder
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimRight(line, " \t")
+		if strings.HasSuffix(trimmed, `\`) && !strings.HasSuffix(trimmed, `\\`) {
+			// A line ending backslash joins the next non-blank line.
+			sb.WriteString(trimmed[:len(trimmed)-1])
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+				i++
+			}
+			if i+1 < len(lines) {
+				lines[i+1] = strings.TrimLeft(lines[i+1], " \t")
+			}
+			continue
+		}
+		sb.WriteString(line)
+		if i < len(lines)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return unescapeString(sb.String())
+}
+
+// openMultiline reports whether src starts a multi-line string that is not
+// closed yet.
+func openMultiline(src string) bool {
+	for _, delim := range []string{`"""`, "'''"} {
+		if strings.HasPrefix(src, delim) {
+			return !strings.Contains(src[3:], delim)
+		}
+	}
+	return false
+}
+
+// tomlScalar converts a bare TOML value.
+func tomlScalar(s string) (interface{}, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		f, _ := strconv.ParseFloat(strings.Replace(s, "inf", "Inf", 1), 64)
+		return f, nil
+	}
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil && !(len(s) > 1 && s[0] == '0' && s[1] >= '0' && s[1] <= '9') {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return f, nil
+	}
+	if len(s) >= 8 && (s[0] >= '0' && s[0] <= '9') && strings.ContainsAny(s, "-:") {
+		// Dates and times are kept as written.
+		return s, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", s)
+}
+
+// flowParser parses inline arrays and tables, which YAML and TOML share
+// apart from the key separator.
+type flowParser struct {
+	s      string
+	i      int
+	sep    byte
+	scalar func(string) (interface{}, error)
+}
+
+func (f *flowParser) parse() (interface{}, error) {
+	v, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	f.skip()
+	if f.i < len(f.s) {
+		return nil, fmt.Errorf("unexpected %q", f.s[f.i:])
+	}
+	return v, nil
+}
+
+// skip skips whitespace, newlines and comments.
+func (f *flowParser) skip() {
+	for f.i < len(f.s) {
+		switch f.s[f.i] {
+		case ' ', '\t', '\r', '\n':
+			f.i++
+		case '#':
+			for f.i < len(f.s) && f.s[f.i] != '\n' {
+				f.i++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (f *flowParser) value() (interface{}, error) {
+	f.skip()
+	if f.i >= len(f.s) {
+		return nil, fmt.Errorf("missing value")
+	}
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		list := []interface{}{}
+		for {
+			f.skip()
+			if f.i < len(f.s) && f.s[f.i] == ']' {
+				f.i++
+				return list, nil
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			if err := f.next(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.i++
+		m := make(map[string]interface{})
+		for {
+			f.skip()
+			if f.i < len(f.s) && f.s[f.i] == '}' {
+				f.i++
+				return m, nil
+			}
+			key, err := f.key()
+			if err != nil {
+				return nil, err
+			}
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			if err := f.next('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		s, rest, err := quotedString(f.s[f.i:])
+		if err != nil {
+			return nil, err
+		}
+		f.i = len(f.s) - len(rest)
+		return s, nil
+	}
+	start := f.i
+	for f.i < len(f.s) && !strings.ContainsRune(",]}\n", rune(f.s[f.i])) && !(f.s[f.i] == '#' && f.i > start && f.s[f.i-1] == ' ') {
+		f.i++
+	}
+	return f.scalar(strings.TrimSpace(f.s[start:f.i]))
+}
+
+// next consumes the comma between items, leaving a closing bracket.
+func (f *flowParser) next(closing byte) error {
+	f.skip()
+	if f.i >= len(f.s) {
+		return fmt.Errorf("missing %q", closing)
+	}
+	switch f.s[f.i] {
+	case ',':
+		f.i++
+		return nil
+	case closing:
+		return nil
+	}
+	return fmt.Errorf("unexpected %q", f.s[f.i:])
+}
+
+func (f *flowParser) key() (string, error) {
+	if f.i >= len(f.s) {
+		return "", fmt.Errorf("missing %q", '}')
+	}
+	var key string
+	if f.s[f.i] == '"' || f.s[f.i] == '\'' {
+		k, rest, err := quotedString(f.s[f.i:])
+		if err != nil {
+			return "", err
+		}
+		key, f.i = k, len(f.s)-len(rest)
+	} else {
+		start := f.i
+		for f.i < len(f.s) && f.s[f.i] != f.sep && !strings.ContainsRune(",}\n", rune(f.s[f.i])) {
+			f.i++
+		}
+		key = strings.TrimSpace(f.s[start:f.i])
+	}
+	f.skip()
+	if f.i >= len(f.s) || f.s[f.i] != f.sep || key == "" {
+		return "", fmt.Errorf("expected key %c value", f.sep)
+	}
+	f.i++
+	return key, nil
+}
+
+// flowBalanced reports whether the brackets and braces in s, outside of
+// strings and comments, are balanced.
+func flowBalanced(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			_, rest, err := quotedString(s[i:])
+			if err != nil {
+				return strings.Count(s[i:], "\n") == 0
+			}
+			i = len(s) - len(rest) - 1
+		case '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// indexOutsideQuotes returns the index of the first c in s that is not
+// inside a quoted string, or -1.
+func indexOutsideQuotes(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case c:
+			return i
+		case '"', '\'':
+			_, rest, err := quotedString(s[i:])
+			if err != nil {
+				return -1
+			}
+			i = len(s) - len(rest) - 1
+		}
+	}
+	return -1
+}
+
+// quotedString parses the single or double quoted string at the start of
+// s and returns it with the rest of s. In single quoted strings a doubled
+// quote stands for a quote.
+func quotedString(s string) (string, string, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\n':
+			return "", "", fmt.Errorf("unterminated string")
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			if q == '\'' {
+				return strings.ReplaceAll(s[1:i], "''", "'"), s[i+1:], nil
+			}
+			v, err := unescapeString(s[1:i])
+			return v, s[i+1:], err
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// unescapeString resolves the backslash escapes of a double quoted string.
+func unescapeString(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid escape at end of string")
+		}
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case '0':
+			sb.WriteByte(0)
+		case '"', '\\', '/', '\'':
+			sb.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("invalid escape \\%s", s[i:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf("invalid escape \\%s", s[i:i+1+n])
+			}
+			sb.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// FormatFrontMatter encodes fm as front matter in the given format,
+// including its delimiters. Keys are sorted, so equal maps always produce
+// the same output. TOML has no null value, so nil values are omitted from
+// TOML front matter.
+//
+// Integers are written as integers and floating-point numbers always with
+// a fraction or exponent, so that ParseFrontMatter returns them as int64
+// and float64 respectively. A json.Number is written as an integer if it
+// has no fraction or exponent.
+func FormatFrontMatter(fm map[string]interface{}, format string) ([]byte, error) {
+	if fm != nil {
+		fm, _ = frontMatterValue(fm).(map[string]interface{})
+	}
+	var sb strings.Builder
+	switch format {
+	case FrontMatterYAML, "":
+		sb.WriteString("---\n")
+		if len(fm) > 0 {
+			if err := writeYAMLMapping(&sb, fm, 0); err != nil {
+				return nil, err
+			}
+		}
+		sb.WriteString("---\n")
+	case FrontMatterTOML:
+		sb.WriteString("+++\n")
+		if err := writeTOMLTable(&sb, fm, nil); err != nil {
+			return nil, err
+		}
+		sb.WriteString("+++\n")
+	case FrontMatterJSON:
+		if fm == nil {
+			fm = map[string]interface{}{}
+		}
+		b, err := json.MarshalIndent(fm, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		sb.Write(b)
+		sb.WriteString("\n")
+	default:
+		return nil, fmt.Errorf("unknown front matter format %q", format)
+	}
+	return []byte(sb.String()), nil
+}
+
+func writeYAMLMapping(sb *strings.Builder, m map[string]interface{}, indent int) error {
+	pad := strings.Repeat(" ", indent)
+	for _, key := range sortedKeys(m) {
+		sb.WriteString(pad)
+		sb.WriteString(yamlKey(key))
+		sb.WriteString(":")
+		if err := writeYAMLValue(sb, m[key], indent); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// writeYAMLValue writes the value of a key or list item, starting on the
+// line of the key.
+func writeYAMLValue(sb *strings.Builder, v interface{}, indent int) error {
+	pad := strings.Repeat(" ", indent+2)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			sb.WriteString(" {}\n")
+			return nil
+		}
+		sb.WriteString("\n")
+		return writeYAMLMapping(sb, v, indent+2)
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString(" []\n")
+			return nil
+		}
+		sb.WriteString("\n")
+		for _, item := range v {
+			sb.WriteString(pad)
+			sb.WriteString("-")
+			if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+				// Write the mapping as if indented under the dash, then
+				// put its first key on the dash's line.
+				var item strings.Builder
+				if err := writeYAMLMapping(&item, m, indent+4); err != nil {
+					return err
+				}
+				sb.WriteString(" ")
+				sb.WriteString(item.String()[indent+4:])
+				continue
+			}
+			if err := writeYAMLValue(sb, item, indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	case string:
+		if strings.Contains(v, "\n") && yamlBlockSafe(v) {
+			header := "|-"
+			if strings.HasSuffix(v, "\n") {
+				header = "|"
+			}
+			sb.WriteString(" " + header + "\n")
+			for _, line := range strings.Split(strings.TrimSuffix(v, "\n"), "\n") {
+				if line != "" {
+					sb.WriteString(pad)
+					sb.WriteString(line)
+				}
+				sb.WriteString("\n")
+			}
+			return nil
+		}
+	}
+	s, err := scalarString(v, yamlString)
+	if err != nil {
+		return err
+	}
+	sb.WriteString(" " + s + "\n")
+	return nil
+}
+
+// yamlBlockSafe reports whether s can be written as a literal block
+// scalar and read back unchanged.
+func yamlBlockSafe(s string) bool {
+	return !strings.HasPrefix(s, " ") && !strings.HasPrefix(s, "\n") && !strings.HasSuffix(s, "\n\n") &&
+		!strings.ContainsAny(s, "\r\t") && !strings.Contains(s, " \n") && !strings.HasSuffix(s, " ")
+}
+
+func yamlKey(key string) string {
+	if bareKeyRE(key, true) {
+		return key
+	}
+	return quoteString(key)
+}
+
+// yamlString returns s as a plain scalar if it reads back as the same
+// string, and quoted otherwise.
+func yamlString(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") || strings.ContainsAny(s, "\n\r\t") {
+		return quoteString(s)
+	}
+	if v, _ := yamlScalar(s); v != s {
+		return quoteString(s)
+	}
+	return s
+}
+
+// scalarString formats a scalar, quoting strings with quote.
+func scalarString(v interface{}, quote func(string) string) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return quote(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", fmt.Errorf("unsupported value %v", v)
+		}
+		return formatFloat(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %T", v)
+}
+
+// formatFloat formats f with a fraction or exponent, so that it does not
+// read back as an integer.
+func formatFloat(f float64) string {
+	var s string
+	if abs := math.Abs(f); abs == 0 || abs >= 1e-4 && abs < 1e21 {
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	} else {
+		s = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// frontMatterValue converts v to the types FormatFrontMatter writes:
+// maps, lists, strings, booleans, int64 and float64. Other values are
+// converted through their JSON encoding.
+func frontMatterValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, int64, float64:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = frontMatterValue(val)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, val := range v {
+			list[i] = frontMatterValue(val)
+		}
+		return list
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return v
+	}
+	return frontMatterValue(out)
+}
+
+// quoteString returns s as a double quoted string valid in YAML and TOML.
+func quoteString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// writeTOMLTable writes the keys of table at path: plain values first,
+// then subtables and arrays of tables.
+func writeTOMLTable(sb *strings.Builder, m map[string]interface{}, path []string) error {
+	var tables []string
+	for _, key := range sortedKeys(m) {
+		switch v := m[key].(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			tables = append(tables, key)
+			continue
+		case []interface{}:
+			if isTableArray(v) {
+				tables = append(tables, key)
+				continue
+			}
+		}
+		s, err := tomlValue(m[key])
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		sb.WriteString(tomlKey(key) + " = " + s + "\n")
+	}
+	for _, key := range tables {
+		sub := append(append([]string(nil), path...), key)
+		header := make([]string, len(sub))
+		for i, k := range sub {
+			header[i] = tomlKey(k)
+		}
+		switch v := m[key].(type) {
+		case map[string]interface{}:
+			sb.WriteString("\n[" + strings.Join(header, ".") + "]\n")
+			if err := writeTOMLTable(sb, v, sub); err != nil {
+				return err
+			}
+		case []interface{}:
+			for _, item := range v {
+				sb.WriteString("\n[[" + strings.Join(header, ".") + "]]\n")
+				if err := writeTOMLTable(sb, item.(map[string]interface{}), sub); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isTableArray reports whether a list holds only tables, and is written
+// as an array of tables.
+func isTableArray(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// tomlValue formats an inline TOML value.
+func tomlValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		parts := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			if v[key] == nil {
+				continue
+			}
+			s, err := tomlValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(key)+" = "+s)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if item == nil {
+				return "", fmt.Errorf("null in array")
+			}
+			s, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+	return scalarString(v, quoteString)
+}
+
+func tomlKey(key string) string {
+	if bareKeyRE(key, false) {
+		return key
+	}
+	return quoteString(key)
+}
+
+// bareKeyRE reports whether key can be written unquoted. YAML keys may
+// also contain dots.
+func bareKeyRE(key string, dots bool) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		case r == '.' && dots:
+		default:
+			return false
+		}
+	}
+	if dots {
+		if v, _ := yamlScalar(key); v != key || key[0] == '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
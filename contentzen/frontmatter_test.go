@@ -0,0 +1,209 @@
+package contentzen
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		want   map[string]interface{}
+		body   string
+		format string
+	}{
+		{
+			name: "none",
+			in:   "# Title\n",
+			body: "# Title\n",
+		},
+		{
+			name: "yaml scalars",
+			in:   "---\ntitle: Hello: world\ncount: 3\nratio: 0.0\ndraft: true\nempty:\nquoted: \"a\\tb\" # comment\nsingle: 'it''s'\n---\nbody\n",
+			want: map[string]interface{}{
+				"title": "Hello: world", "count": int64(3), "ratio": 0.0, "draft": true,
+				"empty": nil, "quoted": "a\tb", "single": "it's",
+			},
+			body:   "body\n",
+			format: FrontMatterYAML,
+		},
+		{
+			name: "yaml nested",
+			in:   "---\ntags:\n  - a\n  - b\nauthor:\n  name: Ann\n  links: [x, \"y\"]\nitems:\n  - id: 1\n    name: one\nmeta: {a: 1, b: [2]}\n---\n",
+			want: map[string]interface{}{
+				"tags":   []interface{}{"a", "b"},
+				"author": map[string]interface{}{"name": "Ann", "links": []interface{}{"x", "y"}},
+				"items":  []interface{}{map[string]interface{}{"id": int64(1), "name": "one"}},
+				"meta":   map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2)}},
+			},
+			body:   "",
+			format: FrontMatterYAML,
+		},
+		{
+			name:   "yaml block scalars",
+			in:     "---\nliteral: |\n  a\n  b\nfolded: >-\n  a\n  b\n...\nbody",
+			want:   map[string]interface{}{"literal": "a\nb\n", "folded": "a b"},
+			body:   "body",
+			format: FrontMatterYAML,
+		},
+		{
+			name: "toml",
+			in:   "+++\ntitle = \"Hello\"\ncount = 0x10\nratio = 1.5\ndate = 2024-01-02\ntags = [\"a\", 'b']\n\n[author]\nname = \"Ann\"\n\n[[items]]\nid = 1\n\n[[items]]\nid = 2\n+++\nbody\n",
+			want: map[string]interface{}{
+				"title": "Hello", "count": int64(16), "ratio": 1.5, "date": "2024-01-02",
+				"tags":   []interface{}{"a", "b"},
+				"author": map[string]interface{}{"name": "Ann"},
+				"items":  []interface{}{map[string]interface{}{"id": int64(1)}, map[string]interface{}{"id": int64(2)}},
+			},
+			body:   "body\n",
+			format: FrontMatterTOML,
+		},
+		{
+			name:   "toml multiline",
+			in:     "+++\ntext = \"\"\"\nline \\\n  one\ntwo\"\"\"\n+++\n",
+			want:   map[string]interface{}{"text": "line one\ntwo"},
+			body:   "",
+			format: FrontMatterTOML,
+		},
+		{
+			name:   "json",
+			in:     "{\"title\": \"Hello\", \"n\": 1}\n\nbody\n",
+			want:   map[string]interface{}{"title": "Hello", "n": 1.0},
+			body:   "body\n",
+			format: FrontMatterJSON,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, format, err := ParseFrontMatter([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fm, tt.want) {
+				t.Errorf("front matter = %#v, want %#v", fm, tt.want)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if format != tt.format {
+				t.Errorf("format = %q, want %q", format, tt.format)
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterErrors(t *testing.T) {
+	tests := []string{
+		"---\ntitle: a\n",
+		"+++\ntitle = \"a\"\n",
+		"---\na: {\n---\n",
+		"---\na: [1,\n---\n",
+		"---\na: {b\n---\n",
+		"---\na: \"unterminated\n---\n",
+		"---\n  a: 1\nb: 2\n---\n",
+		"+++\na = {\n+++\n",
+		"+++\na = {b = 1,\n+++\n",
+		"+++\na = 1\na = 2\n+++\n",
+		"+++\na = bare\n+++\n",
+		"{\"a\": ",
+	}
+	for _, in := range tests {
+		if _, _, _, err := ParseFrontMatter([]byte(in)); !errors.Is(err, ErrFrontMatter) {
+			t.Errorf("ParseFrontMatter(%q) error = %v, want ErrFrontMatter", in, err)
+		}
+	}
+}
+
+func TestFormatFrontMatterRoundTrip(t *testing.T) {
+	fm := map[string]interface{}{
+		"title":   "Hello: world",
+		"yes":     "true",
+		"number":  "12",
+		"count":   3,
+		"zero":    0.0,
+		"ratio":   1.5,
+		"big":     1e21,
+		"small":   1e-7,
+		"draft":   false,
+		"text":    "line one\nline two\n",
+		"tags":    []interface{}{"a", int64(1), 2.0},
+		"author":  map[string]interface{}{"name": "Ann", "dotted.key": "x"},
+		"items":   []interface{}{map[string]interface{}{"id": int64(1)}, map[string]interface{}{"id": int64(2)}},
+		"empty":   map[string]interface{}{},
+		"nothing": []interface{}{},
+	}
+	want := map[string]interface{}{
+		"title":   "Hello: world",
+		"yes":     "true",
+		"number":  "12",
+		"count":   int64(3),
+		"zero":    0.0,
+		"ratio":   1.5,
+		"big":     1e21,
+		"small":   1e-7,
+		"draft":   false,
+		"text":    "line one\nline two\n",
+		"tags":    []interface{}{"a", int64(1), 2.0},
+		"author":  map[string]interface{}{"name": "Ann", "dotted.key": "x"},
+		"items":   []interface{}{map[string]interface{}{"id": int64(1)}, map[string]interface{}{"id": int64(2)}},
+		"empty":   map[string]interface{}{},
+		"nothing": []interface{}{},
+	}
+	for _, format := range []string{FrontMatterYAML, FrontMatterTOML} {
+		t.Run(format, func(t *testing.T) {
+			b, err := FormatFrontMatter(fm, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, body, gotFormat, err := ParseFrontMatter(append(b, "body\n"...))
+			if err != nil {
+				t.Fatalf("%v in\n%s", err, b)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %#v, want %#v\nfront matter:\n%s", got, want, b)
+			}
+			if string(body) != "body\n" || gotFormat != format {
+				t.Errorf("body = %q, format = %q", body, gotFormat)
+			}
+		})
+	}
+}
+
+func TestFormatFrontMatterNumbers(t *testing.T) {
+	b, err := FormatFrontMatter(map[string]interface{}{"a": 0.0, "b": 3, "c": 2.5, "d": int64(1) << 60}, FrontMatterYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\na: 0.0\nb: 3\nc: 2.5\nd: 1152921504606846976\n---\n"; string(b) != want {
+		t.Errorf("FormatFrontMatter = %q, want %q", b, want)
+	}
+}
+
+func FuzzParseFrontMatter(f *testing.F) {
+	f.Add([]byte("---\ntitle: a\ntags: [a, b]\nn: 1.0\n---\nbody"))
+	f.Add([]byte("---\na: {\n---\n"))
+	f.Add([]byte("---\nitems:\n  - id: 1\n    x: |\n      text\n---\n"))
+	f.Add([]byte("+++\na = { b = [1, 'c'] }\n[t]\nx = \"\"\"\ny\"\"\"\n+++\n"))
+	f.Add([]byte("{\"a\": 1}\nbody"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fm, _, format, err := ParseFrontMatter(data)
+		if err != nil || fm == nil || format == FrontMatterJSON || !utf8.Valid(data) {
+			return
+		}
+		// Formatted front matter must read back unchanged.
+		b, err := FormatFrontMatter(fm, format)
+		if err != nil {
+			return
+		}
+		got, _, _, err := ParseFrontMatter(b)
+		if err != nil {
+			t.Fatalf("reparse %q: %v", b, err)
+		}
+		if !reflect.DeepEqual(got, fm) {
+			t.Fatalf("round trip of %q = %#v, want %#v", b, got, fm)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package contentzen
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Fit controls how an image is resized to the requested dimensions.
+type Fit string
+
+// Fit modes for ImageURL.Fit.
+const (
+	// Cover crops the image to fill both dimensions.
+	Cover Fit = "cover"
+	// Contain fits the image within both dimensions, letterboxing it.
+	Contain Fit = "contain"
+	// Fill stretches the image to both dimensions.
+	Fill Fit = "fill"
+	// Inside fits the image within both dimensions without letterboxing.
+	Inside Fit = "inside"
+	// Outside resizes the image to cover both dimensions without cropping.
+	Outside Fit = "outside"
+)
+
+// ImageFormat is the output format of a transformed image.
+type ImageFormat string
+
+// Output formats for ImageURL.Format.
+const (
+	WebP ImageFormat = "webp"
+	AVIF ImageFormat = "avif"
+	JPEG ImageFormat = "jpeg"
+	PNG  ImageFormat = "png"
+	// AutoFormat lets the server pick the best format the browser accepts.
+	AutoFormat ImageFormat = "auto"
+)
+
+// ImageURL builds the URL of a resized, cropped or converted variant of an
+// image. Its methods return modified copies, so a partially configured
+// ImageURL can be reused as a template.
+type ImageURL struct {
+	base    string
+	width   int
+	height  int
+	fit     Fit
+	format  ImageFormat
+	quality int
+	dpr     float64
+	crop    [4]int
+	cropped bool
+}
+
+// ImageURL returns a builder for transformed variants of the media's image.
+func (m Media) ImageURL() ImageURL {
+	return ImageURL{base: m.URL}
+}
+
+// Width sets the output width in pixels.
+func (u ImageURL) Width(px int) ImageURL { u.width = px; return u }
+
+// Height sets the output height in pixels.
+func (u ImageURL) Height(px int) ImageURL { u.height = px; return u }
+
+// Fit sets how the image is fitted to the output dimensions.
+func (u ImageURL) Fit(fit Fit) ImageURL { u.fit = fit; return u }
+
+// Format sets the output format.
+func (u ImageURL) Format(format ImageFormat) ImageURL { u.format = format; return u }
+
+// Quality sets the output quality from 1 to 100 for lossy formats.
+func (u ImageURL) Quality(q int) ImageURL { u.quality = q; return u }
+
+// DPR sets the device pixel ratio the dimensions are multiplied by.
+func (u ImageURL) DPR(dpr float64) ImageURL { u.dpr = dpr; return u }
+
+// Crop crops the source image to the given rectangle before resizing.
+func (u ImageURL) Crop(x, y, width, height int) ImageURL {
+	u.crop = [4]int{x, y, width, height}
+	u.cropped = true
+	return u
+}
+
+// String returns the transformation URL. Query parameters already present
+// in the media URL are kept.
+func (u ImageURL) String() string {
+	parsed, err := url.Parse(u.base)
+	if err != nil {
+		return u.base
+	}
+	q := parsed.Query()
+	if u.width > 0 {
+		q.Set("w", strconv.Itoa(u.width))
+	}
+	if u.height > 0 {
+		q.Set("h", strconv.Itoa(u.height))
+	}
+	if u.fit != "" {
+		q.Set("fit", string(u.fit))
+	}
+	if u.format != "" {
+		q.Set("fm", string(u.format))
+	}
+	if u.quality > 0 {
+		q.Set("q", strconv.Itoa(min(u.quality, 100)))
+	}
+	if u.dpr > 0 {
+		q.Set("dpr", strconv.FormatFloat(u.dpr, 'f', -1, 64))
+	}
+	if u.cropped {
+		q.Set("rect", fmt.Sprintf("%d,%d,%d,%d", u.crop[0], u.crop[1], u.crop[2], u.crop[3]))
+	}
+	parsed.RawQuery = q.Encode()
+	return parsed.String()
+}
+
+// SrcSet returns a srcset attribute value with one candidate per width.
+// If a height is set, it is scaled to keep the aspect ratio of each
+// candidate. The value is a plain string, so html/template still checks
+// the URL of every candidate.
+func (u ImageURL) SrcSet(widths ...int) string {
+	candidates := make([]string, 0, len(widths))
+	for _, w := range widths {
+		v := u.Width(w)
+		if u.width > 0 && u.height > 0 {
+			v = v.Height(u.height * w / u.width)
+		}
+		candidates = append(candidates, fmt.Sprintf("%s %dw", srcsetURL(v.String()), w))
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// DensitySrcSet returns a srcset attribute value with one candidate per
+// device pixel ratio, such as 1, 2 and 3.
+func (u ImageURL) DensitySrcSet(densities ...float64) string {
+	candidates := make([]string, 0, len(densities))
+	for _, d := range densities {
+		candidates = append(candidates, fmt.Sprintf("%s %sx", srcsetURL(u.DPR(d).String()), strconv.FormatFloat(d, 'f', -1, 64)))
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// srcsetURL percent-encodes the characters that end the URL of a srcset
+// candidate: commas, which separate candidates, and ASCII whitespace, which
+// separates the URL from its descriptor. They can remain in a media URL
+// that String could not parse.
+func srcsetURL(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ',', ' ', '\t', '\n', '\f', '\r':
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ImageFuncs returns html/template functions for responsive images:
+//
+//	imageURL  media width        -> URL of the media resized to width
+//	srcset    media widths...    -> srcset with one candidate per width
+//
+// For example:
+//
+//	<img src="{{imageURL .Hero 800}}" srcset="{{srcset .Hero 400 800 1200}}" sizes="100vw">
+func ImageFuncs() template.FuncMap {
+	return template.FuncMap{
+		"imageURL": func(m Media, width int) string {
+			return m.ImageURL().Width(width).String()
+		},
+		"srcset": func(m Media, widths ...int) string {
+			return m.ImageURL().SrcSet(widths...)
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package contentzen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrLocaleNotFound is returned when a content entry has no variant in any
+// of the requested languages.
+var ErrLocaleNotFound = errors.New("no variant in the requested languages")
+
+// FallbackChain returns the languages to try for locale, from most to least
+// specific, followed by defaults. For example FallbackChain("de-AT", "en")
+// returns ["de-AT", "de", "en"]. Duplicates are removed.
+func FallbackChain(locale string, defaults ...string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(lang string) {
+		if lang != "" && !seen[normalizeLang(lang)] {
+			seen[normalizeLang(lang)] = true
+			chain = append(chain, lang)
+		}
+	}
+	tag := strings.ReplaceAll(locale, "_", "-")
+	for tag != "" {
+		add(tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	for _, lang := range defaults {
+		add(lang)
+	}
+	return chain
+}
+
+// SelectLocale returns the first variant whose Lang matches a language in
+// chain. Languages are compared case-insensitively, treating "_" as "-".
+func SelectLocale(variants []Document, chain []string) (*Document, bool) {
+	for _, lang := range chain {
+		for i := range variants {
+			if normalizeLang(variants[i].Lang) == normalizeLang(lang) {
+				return &variants[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+}
+
+// ListLocales fetches every language variant of the content entry that
+// documentUUID belongs to, whatever their state (requires API token).
+func (c *Client) ListLocales(collectionUUID, documentUUID string) ([]Document, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/locales/%s/%s", c.BaseURL, collectionUUID, documentUUID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var docs []Document
+	if err := json.NewDecoder(resp.Body).Decode(&docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// ListPublicLocales fetches the published language variants of the content
+// entry that documentUUID belongs to, from a public collection.
+// documentUUID may be any variant of the entry, including an unpublished
+// one.
+func (c *Client) ListPublicLocales(collectionUUID, documentUUID string) ([]Document, error) {
+	url := fmt.Sprintf("%s/api/v1/documents/collection/%s/%s/locales", c.BaseURL, collectionUUID, documentUUID)
+	resp, err := c.HTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var result struct {
+		Data []Document `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetLocalizedDocument fetches the variant of documentUUID's content entry
+// in the first available language of FallbackChain(locale, fallbacks...)
+// (requires API token). It returns an error wrapping ErrLocaleNotFound if
+// none exists.
+func (c *Client) GetLocalizedDocument(collectionUUID, documentUUID, locale string, fallbacks ...string) (*Document, error) {
+	variants, err := c.ListLocales(collectionUUID, documentUUID)
+	if err != nil {
+		return nil, err
+	}
+	return selectLocale(variants, locale, fallbacks)
+}
+
+// GetPublicLocalizedDocument is like GetLocalizedDocument but only
+// considers published variants in a public collection.
+func (c *Client) GetPublicLocalizedDocument(collectionUUID, documentUUID, locale string, fallbacks ...string) (*Document, error) {
+	variants, err := c.ListPublicLocales(collectionUUID, documentUUID)
+	if err != nil {
+		return nil, err
+	}
+	return selectLocale(variants, locale, fallbacks)
+}
+
+func selectLocale(variants []Document, locale string, fallbacks []string) (*Document, error) {
+	chain := FallbackChain(locale, fallbacks...)
+	doc, ok := SelectLocale(variants, chain)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLocaleNotFound, strings.Join(chain, ", "))
+	}
+	return doc, nil
+}
+
+// PutLocale creates or replaces the lang variant of the content entry that
+// documentUUID belongs to (requires API token). doc.Lang is ignored in
+// favour of lang.
+func (c *Client) PutLocale(collectionUUID, documentUUID, lang string, doc *Document) (*Document, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/locales/%s/%s/%s", c.BaseURL, collectionUUID, documentUUID, url.PathEscape(lang))
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var saved Document
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -0,0 +1,608 @@
+package contentzen
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImportOptions configures ImportMarkdown.
+type ImportOptions struct {
+	// Fields maps front matter keys to collection field names. Keys that
+	// are not mapped are matched to the field of the same name, ignoring
+	// case. A key mapped to "" or "-" is dropped.
+	Fields map[string]string
+	// BodyField is the field receiving the Markdown body. Defaults to a
+	// field named "body" or "content", or else the first markdown field.
+	BodyField string
+	// Body converts the Markdown body, after its links are rewritten, to
+	// the value stored in the body field. It is required if the body field
+	// is a rich-text field; by default the Markdown is stored as is.
+	Body func(markdown string) (interface{}, error)
+	// State is the state of the created documents. By default a file is
+	// published if its front matter sets "draft: false" and is a draft
+	// otherwise.
+	State DocumentState
+	// Lang is the language of the created documents, unless the front
+	// matter sets "lang" or "language".
+	Lang string
+	// StaticDir is where root-relative file references such as
+	// "/images/a.png" are looked up. Defaults to the imported directory.
+	StaticDir string
+	// MediaFolder and MediaTags organize the uploaded files in the media
+	// library.
+	MediaFolder string
+	MediaTags   []string
+	// LinkURL returns the URL a link to another imported Markdown file is
+	// rewritten to, given the file's slash-separated path relative to the
+	// imported directory. By default "posts/hello.md" becomes
+	// "/posts/hello/" and "posts/_index.md" becomes "/posts/".
+	LinkURL func(rel string) string
+	// Ignore lists path.Match patterns; files whose slash-separated path
+	// relative to the directory, or whose base name, matches one are
+	// skipped. Hidden files and directories are always skipped.
+	Ignore []string
+	// DryRun builds the documents without uploading files or creating
+	// documents. References to local files are left unchanged.
+	DryRun bool
+	// Concurrency is the number of files imported in parallel. Defaults
+	// to 4.
+	Concurrency int
+}
+
+// ImportReport describes the result of ImportMarkdown. Paths are
+// slash-separated and relative to the imported directory.
+type ImportReport struct {
+	// Documents maps the paths of imported files to the documents created
+	// from them. In a dry run, the documents are built but not created.
+	Documents map[string]*Document
+	// Media maps the paths of uploaded files to the created media.
+	Media map[string]*Media
+	// Unmapped lists, for each file, the front matter keys that matched no
+	// field and were dropped.
+	Unmapped map[string][]string
+	// Errors holds per-file failures; the other files are still imported.
+	Errors []error
+}
+
+// markdownExtensions are the extensions of the files ImportMarkdown reads.
+var markdownExtensions = []string{".md", ".markdown"}
+
+// ImportMarkdown creates a document in a collection for each Markdown file
+// under dir, or for the single file dir names (requires API token).
+//
+// The file's front matter (see ParseFrontMatter) is mapped onto the
+// collection's fields and its body is stored in the body field. Values are
+// converted to the field types: dates are normalized to RFC 3339, and the
+// local files named by media fields are uploaded and replaced with their
+// UUIDs. In the body, images and links to other local files are uploaded
+// and rewritten to the media URLs, and links to other Markdown files are
+// rewritten with opts.LinkURL. Each file is uploaded only once.
+func (c *Client) ImportMarkdown(ctx context.Context, collectionUUID, dir string, opts *ImportOptions) (*ImportReport, error) {
+	o := ImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.LinkURL == nil {
+		o.LinkURL = defaultLinkURL
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if info.IsDir() {
+		if files, err = scanMarkdownDir(dir, o.Ignore); err != nil {
+			return nil, err
+		}
+	} else {
+		files = []string{filepath.Base(dir)}
+		dir = filepath.Dir(dir)
+	}
+	if o.StaticDir == "" {
+		o.StaticDir = dir
+	}
+
+	fields, err := c.GetCollectionFields(collectionUUID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := importBodyField(fields, o)
+	if err != nil {
+		return nil, err
+	}
+
+	im := &importer{
+		c:       c,
+		opts:    o,
+		dir:     dir,
+		fields:  fields,
+		body:    body,
+		uploads: make(map[string]*importUpload),
+		report: &ImportReport{
+			Documents: make(map[string]*Document),
+			Media:     make(map[string]*Media),
+			Unmapped:  make(map[string][]string),
+		},
+	}
+	runWorkers(ctx, sliceChan(ctx, files), o.Concurrency, func(rel string) {
+		doc, unmapped, err := im.importFile(ctx, collectionUUID, rel)
+		im.mu.Lock()
+		defer im.mu.Unlock()
+		if err != nil {
+			im.report.Errors = append(im.report.Errors, fmt.Errorf("import %s: %w", rel, err))
+			return
+		}
+		im.report.Documents[rel] = doc
+		if len(unmapped) > 0 {
+			im.report.Unmapped[rel] = unmapped
+		}
+	})
+	return im.report, ctx.Err()
+}
+
+// importBodyField returns the field receiving the Markdown body.
+func importBodyField(fields []CollectionField, o ImportOptions) (*CollectionField, error) {
+	var body *CollectionField
+	for i, f := range fields {
+		if o.BodyField != "" && f.Name == o.BodyField ||
+			o.BodyField == "" && (strings.EqualFold(f.Name, "body") || strings.EqualFold(f.Name, "content")) {
+			body = &fields[i]
+			break
+		}
+	}
+	if body == nil && o.BodyField == "" {
+		for i, f := range fields {
+			if f.Type == FieldTypeMarkdown {
+				body = &fields[i]
+				break
+			}
+		}
+	}
+	if body == nil {
+		if o.BodyField != "" {
+			return nil, fmt.Errorf("collection has no field %s", o.BodyField)
+		}
+		return nil, fmt.Errorf("collection has no body field; set ImportOptions.BodyField")
+	}
+	if body.Type == FieldTypeRichText && o.Body == nil {
+		return nil, fmt.Errorf("body field %s is rich text; set ImportOptions.Body", body.Name)
+	}
+	return body, nil
+}
+
+type importer struct {
+	c      *Client
+	opts   ImportOptions
+	dir    string
+	fields []CollectionField
+	body   *CollectionField
+
+	mu      sync.Mutex
+	uploads map[string]*importUpload
+	report  *ImportReport
+}
+
+// importUpload is a local file uploaded once for all files referencing it.
+type importUpload struct {
+	once  sync.Once
+	media *Media
+	err   error
+}
+
+func (im *importer) importFile(ctx context.Context, collectionUUID, rel string) (*Document, []string, error) {
+	data, err := os.ReadFile(filepath.Join(im.dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, nil, err
+	}
+	fm, body, _, err := ParseFrontMatter(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := &Document{Payload: make(map[string]interface{}), Lang: im.opts.Lang, State: string(im.opts.State)}
+	if doc.State == "" {
+		doc.State = string(StateDraft)
+	}
+	var unmapped []string
+	for key, value := range fm {
+		f := im.field(key)
+		if f == nil {
+			if !im.special(doc, key, value) {
+				unmapped = append(unmapped, key)
+			}
+			continue
+		}
+		if f.Name == im.body.Name {
+			continue
+		}
+		v, err := im.convert(ctx, rel, f, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		doc.Payload[f.Name] = v
+	}
+	sort.Strings(unmapped)
+
+	markdown, err := rewriteMarkdownLinks(string(body), func(target string) (string, error) {
+		return im.rewriteTarget(ctx, rel, target)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var bodyValue interface{} = markdown
+	if im.opts.Body != nil {
+		if bodyValue, err = im.opts.Body(markdown); err != nil {
+			return nil, nil, fmt.Errorf("convert body: %w", err)
+		}
+	}
+	doc.Payload[im.body.Name] = bodyValue
+
+	if im.opts.DryRun {
+		return doc, unmapped, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	created, err := im.c.createDocument(ctx, collectionUUID, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, unmapped, nil
+}
+
+// field returns the field a front matter key maps to, or nil.
+func (im *importer) field(key string) *CollectionField {
+	name, mapped := im.opts.Fields[key]
+	if mapped && (name == "" || name == "-") {
+		return nil
+	}
+	for i, f := range im.fields {
+		if mapped && f.Name == name || !mapped && strings.EqualFold(f.Name, key) {
+			return &im.fields[i]
+		}
+	}
+	return nil
+}
+
+// special applies the front matter keys that set document properties
+// rather than fields, reporting whether key was one of them.
+func (im *importer) special(doc *Document, key string, value interface{}) bool {
+	switch strings.ToLower(key) {
+	case "draft":
+		draft, ok := value.(bool)
+		if ok && im.opts.State == "" && !draft {
+			doc.State = string(StatePublished)
+		}
+		return ok
+	case "lang", "language":
+		lang, ok := value.(string)
+		if ok && lang != "" {
+			doc.Lang = lang
+		}
+		return ok
+	}
+	return false
+}
+
+// dateLayouts are the date formats accepted for date fields.
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05", "2006-01-02"}
+
+// convert converts a front matter value to the type of field f.
+func (im *importer) convert(ctx context.Context, rel string, f *CollectionField, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch f.Type {
+	case FieldTypeText, FieldTypeMarkdown:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case bool, int64, float64:
+			return fmt.Sprint(v), nil
+		}
+		return nil, fmt.Errorf("expected a string, got %T", value)
+	case FieldTypeNumber:
+		switch v := value.(type) {
+		case int64, float64:
+			return v, nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", v)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected a number, got %T", value)
+	case FieldTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean %q", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %T", value)
+	case FieldTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a date, got %T", value)
+		}
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+				return t.Format(time.RFC3339), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date %q", s)
+	case FieldTypeMedia:
+		switch v := value.(type) {
+		case string:
+			return im.mediaUUID(ctx, rel, v)
+		case []interface{}:
+			uuids := make([]interface{}, len(v))
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected a file path, got %T", item)
+				}
+				id, err := im.mediaUUID(ctx, rel, s)
+				if err != nil {
+					return nil, err
+				}
+				uuids[i] = id
+			}
+			return uuids, nil
+		}
+		return nil, fmt.Errorf("expected a file path, got %T", value)
+	}
+	return value, nil
+}
+
+// mediaUUID uploads the local file p referenced from the file rel and
+// returns its media UUID. Other values are returned unchanged.
+func (im *importer) mediaUUID(ctx context.Context, rel, p string) (string, error) {
+	local, ok := im.localPath(rel, p)
+	if !ok || im.opts.DryRun {
+		return p, nil
+	}
+	m, err := im.upload(ctx, local)
+	if err != nil {
+		return "", err
+	}
+	return m.UUID, nil
+}
+
+// localPath resolves a reference from the file rel to a local file,
+// returning false for URLs and fragments. Root-relative references are
+// resolved in StaticDir and others in the imported directory; references
+// that lead out of it, including through symbolic links, also return
+// false.
+func (im *importer) localPath(rel, ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	root, p := im.dir, path.Join(path.Dir(rel), u.Path)
+	if strings.HasPrefix(u.Path, "/") {
+		root, p = im.opts.StaticDir, strings.TrimPrefix(path.Clean(u.Path), "/")
+	}
+	if p != "" && !filepath.IsLocal(filepath.FromSlash(p)) {
+		return "", false
+	}
+	local := filepath.Join(root, filepath.FromSlash(p))
+	if !withinDir(root, local) {
+		return "", false
+	}
+	return local, true
+}
+
+// withinDir reports whether the existing file p is inside dir once
+// symbolic links are resolved. Files that do not exist are not checked.
+func withinDir(dir, p string) bool {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return true
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	r, err := filepath.Rel(root, resolved)
+	return err == nil && (r == "." || filepath.IsLocal(r))
+}
+
+// upload uploads a local file unless it was uploaded already.
+func (im *importer) upload(ctx context.Context, local string) (*Media, error) {
+	im.mu.Lock()
+	u, ok := im.uploads[local]
+	if !ok {
+		u = &importUpload{}
+		im.uploads[local] = u
+	}
+	im.mu.Unlock()
+
+	u.once.Do(func() {
+		u.media, u.err = im.c.UploadMediaFile(ctx, local, &UploadOptions{Folder: im.opts.MediaFolder, Tags: im.opts.MediaTags})
+		if u.err != nil {
+			return
+		}
+		key := local
+		if r, err := filepath.Rel(im.dir, local); err == nil {
+			key = filepath.ToSlash(r)
+		}
+		im.mu.Lock()
+		im.report.Media[key] = u.media
+		im.mu.Unlock()
+	})
+	if u.err != nil {
+		return nil, fmt.Errorf("upload %s: %w", local, u.err)
+	}
+	return u.media, nil
+}
+
+var (
+	// markdownLinkRE matches inline links and images: [text](target "title").
+	markdownLinkRE = regexp.MustCompile(`(!?\[(?:[^\[\]]|\[[^\]]*\])*\]\(\s*)(<[^>\n]*>|[^\s)]+)((?:\s+(?:"[^"]*"|'[^']*'|\([^)]*\)))?\s*\))`)
+	// markdownRefRE matches link reference definitions: [label]: target.
+	markdownRefRE = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>]*>|\S+)(.*)$`)
+	// htmlSrcRE matches the src and href attributes of inline HTML.
+	htmlSrcRE = regexp.MustCompile(`(<(?:img|a|source|video|audio)\b[^>]*?\s(?:src|href)=")([^"]+)(")`)
+	// fenceRE matches the opening or closing line of a fenced code block.
+	fenceRE = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+)
+
+// rewriteMarkdownLinks replaces the targets of the links, images, link
+// reference definitions and HTML src and href attributes of a Markdown
+// document, outside of fenced code blocks, with the result of rewrite.
+// Returning the target unchanged leaves it as written.
+func rewriteMarkdownLinks(body string, rewrite func(target string) (string, error)) (string, error) {
+	var firstErr error
+	replace := func(re *regexp.Regexp, s string) string {
+		return re.ReplaceAllStringFunc(s, func(match string) string {
+			m := re.FindStringSubmatch(match)
+			angle := strings.HasPrefix(m[2], "<") && strings.HasSuffix(m[2], ">")
+			target := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+			rewritten, err := rewrite(target)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return match
+			}
+			if rewritten == target {
+				return match
+			}
+			if angle {
+				return m[1] + "<" + rewritten + ">" + m[3]
+			}
+			return m[1] + strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(rewritten) + m[3]
+		})
+	}
+
+	lines := strings.Split(body, "\n")
+	fence := ""
+	for i, line := range lines {
+		if m := fenceRE.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case m[1][0] == fence[0] && len(m[1]) >= len(fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		line = replace(markdownRefRE, line)
+		line = replace(markdownLinkRE, line)
+		lines[i] = replace(htmlSrcRE, line)
+	}
+	return strings.Join(lines, "\n"), firstErr
+}
+
+// rewriteTarget rewrites a link target found in the file rel: links to
+// Markdown files use LinkURL and other local files are uploaded. Targets
+// that are not regular files are left unchanged.
+func (im *importer) rewriteTarget(ctx context.Context, rel, target string) (string, error) {
+	local, ok := im.localPath(rel, target)
+	if !ok {
+		return target, nil
+	}
+	if isMarkdownFile(local) {
+		linked, err := filepath.Rel(im.dir, local)
+		if err != nil || strings.HasPrefix(filepath.ToSlash(linked), "../") {
+			return target, nil
+		}
+		rewritten := im.opts.LinkURL(filepath.ToSlash(linked))
+		if i := strings.IndexByte(target, '#'); i >= 0 {
+			rewritten += target[i:]
+		}
+		return rewritten, nil
+	}
+	if info, err := os.Stat(local); err != nil || !info.Mode().IsRegular() || im.opts.DryRun {
+		// Not a file, such as a link to a page of the site.
+		return target, nil
+	}
+	m, err := im.upload(ctx, local)
+	if err != nil {
+		return "", err
+	}
+	return m.URL, nil
+}
+
+// defaultLinkURL turns the path of a Markdown file into a pretty URL.
+func defaultLinkURL(rel string) string {
+	p := strings.TrimSuffix(rel, path.Ext(rel))
+	switch path.Base(p) {
+	case "index", "_index":
+		p = path.Dir(p)
+	}
+	if p == "." {
+		return "/"
+	}
+	return "/" + p + "/"
+}
+
+func isMarkdownFile(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	for _, e := range markdownExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// scanMarkdownDir returns the slash-separated paths of the Markdown files
+// under dir, in lexical order.
+func scanMarkdownDir(dir string, ignore []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !isMarkdownFile(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range ignore {
+			if ok, _ := path.Match(pattern, rel); ok {
+				return nil
+			}
+			if ok, _ := path.Match(pattern, d.Name()); ok {
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,139 @@
+package contentzen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListMediaOptions filters and paginates media listings. Zero fields are
+// not filtered on.
+type ListMediaOptions struct {
+	// MimeType matches the media type exactly, or a whole class with a
+	// wildcard such as "image/*".
+	MimeType string
+	Tag      string
+	Folder   string
+	// Name matches filenames containing it, case-insensitively.
+	Name          string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Page is the 1-based page number. Defaults to 1.
+	Page int
+	// PerPage is the page size. Defaults to the server's default.
+	PerPage int
+}
+
+// MediaPage is one page of a media listing.
+type MediaPage struct {
+	Media   []Media `json:"data"`
+	Page    int     `json:"page"`
+	PerPage int     `json:"per_page"`
+	Total   int     `json:"total"`
+}
+
+// HasMore reports whether there are pages after this one.
+func (p *MediaPage) HasMore() bool {
+	return p.PerPage > 0 && p.Page*p.PerPage < p.Total
+}
+
+func (o *ListMediaOptions) values() url.Values {
+	v := url.Values{}
+	if o == nil {
+		return v
+	}
+	if o.MimeType != "" {
+		v.Set("mime_type", o.MimeType)
+	}
+	if o.Tag != "" {
+		v.Set("tag", o.Tag)
+	}
+	if o.Folder != "" {
+		v.Set("folder", o.Folder)
+	}
+	if o.Name != "" {
+		v.Set("name", o.Name)
+	}
+	if !o.CreatedAfter.IsZero() {
+		v.Set("created_after", o.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if !o.CreatedBefore.IsZero() {
+		v.Set("created_before", o.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	if o.Page > 0 {
+		v.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PerPage > 0 {
+		v.Set("per_page", strconv.Itoa(o.PerPage))
+	}
+	return v
+}
+
+// ListMediaPage fetches one page of media files matching opts.
+func (c *Client) ListMediaPage(opts *ListMediaOptions) (*MediaPage, error) {
+	return c.listMediaPage(context.Background(), opts)
+}
+
+func (c *Client) listMediaPage(ctx context.Context, opts *ListMediaOptions) (*MediaPage, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/media", c.BaseURL)
+	if q := opts.values().Encode(); q != "" {
+		url += "?" + q
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var page MediaPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// ListAllMedia fetches every media file matching opts, following pages
+// from opts.Page. It stops at a page other than the one requested, which
+// a server ignoring the page parameter would return forever.
+func (c *Client) ListAllMedia(opts *ListMediaOptions) ([]Media, error) {
+	return c.listAllMedia(context.Background(), opts)
+}
+
+func (c *Client) listAllMedia(ctx context.Context, opts *ListMediaOptions) ([]Media, error) {
+	o := ListMediaOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Page <= 0 {
+		o.Page = 1
+	}
+	var all []Media
+	for {
+		page, err := c.listMediaPage(ctx, &o)
+		if err != nil {
+			return nil, err
+		}
+		if page.Page != 0 && page.Page != o.Page {
+			return all, nil
+		}
+		all = append(all, page.Media...)
+		if len(page.Media) == 0 || page.PerPage <= 0 || o.Page*page.PerPage >= page.Total {
+			return all, nil
+		}
+		o.Page++
+	}
+}
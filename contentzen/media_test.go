@@ -0,0 +1,48 @@
+package contentzen_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+func TestListAllMediaPages(t *testing.T) {
+	tests := []struct {
+		name string
+		// page returns the page number reported for a requested page.
+		page func(requested int) int
+		want int
+	}{
+		{name: "reported", page: func(requested int) int { return requested }, want: 5},
+		{name: "omitted", page: func(int) int { return 0 }, want: 5},
+		{name: "ignored", page: func(int) int { return 1 }, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var requested int
+				fmt.Sscan(r.URL.Query().Get("page"), &requested)
+				page := contentzen.MediaPage{Page: tt.page(requested), PerPage: 2, Total: 5}
+				for i := (requested - 1) * 2; i < min(requested*2, 5); i++ {
+					page.Media = append(page.Media, contentzen.Media{UUID: fmt.Sprint(i)})
+				}
+				json.NewEncoder(w).Encode(page)
+			}))
+			defer srv.Close()
+			c := contentzen.NewClient("token")
+			c.BaseURL = srv.URL
+
+			media, err := c.ListAllMedia(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(media) != tt.want {
+				t.Errorf("got %d media, want %d", len(media), tt.want)
+			}
+		})
+	}
+}
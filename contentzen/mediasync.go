@@ -0,0 +1,329 @@
+package contentzen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultManifestName is the manifest file written by SyncMediaDir in the
+// synced directory unless SyncOptions.ManifestPath is set.
+const DefaultManifestName = ".contentzen-media.json"
+
+// SyncOptions configures SyncMediaDir.
+type SyncOptions struct {
+	// Folder is the media library folder files are uploaded to. Only media
+	// in this folder are considered when comparing with the library.
+	Folder string
+	// Tags are added to every uploaded file.
+	Tags []string
+	// Delete removes media whose local file was deleted, and the previous
+	// version of media whose local file changed. A changed file is always
+	// uploaded as a new media file with a new UUID and URL, so deleting the
+	// previous version breaks documents that still reference it; update
+	// them from the manifest before syncing with Delete.
+	Delete bool
+	// DryRun computes the report without uploading, deleting or writing
+	// the manifest.
+	DryRun bool
+	// Ignore lists path.Match patterns; files whose slash-separated path
+	// relative to the directory, or whose base name, matches one are
+	// skipped. Hidden files and directories are always skipped.
+	Ignore []string
+	// ManifestPath is where the manifest is read and written. Defaults to
+	// DefaultManifestName inside the synced directory.
+	ManifestPath string
+	// Concurrency is the number of files uploaded in parallel. Defaults to 4.
+	Concurrency int
+}
+
+// SyncManifest maps local paths, relative to the synced directory and
+// slash-separated, to the media they were uploaded as.
+type SyncManifest struct {
+	Files map[string]SyncManifestEntry `json:"files"`
+}
+
+// SyncManifestEntry records the media uploaded for a local file.
+type SyncManifestEntry struct {
+	UUID     string `json:"uuid"`
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+}
+
+// SyncReport lists the local paths affected by a sync.
+type SyncReport struct {
+	// Uploaded lists files uploaded for the first time, or again because
+	// their media was deleted from the library.
+	Uploaded []string
+	// Updated lists changed files uploaded as a new version of their media.
+	Updated   []string
+	Unchanged []string
+	// Deleted lists removed local files whose media was deleted.
+	Deleted []string
+	// Orphaned lists removed local files whose media was kept because
+	// Delete was not set.
+	Orphaned []string
+	// Errors holds per-file failures; the other files are still synced.
+	Errors   []error
+	Manifest *SyncManifest
+}
+
+type localFile struct {
+	rel      string
+	abs      string
+	checksum string
+	size     int64
+}
+
+// SyncMediaDir mirrors the files under dir into the media library (requires
+// API token). Files are hashed and compared with the manifest and with
+// ListAllMedia; new and changed files are uploaded and, with opts.Delete,
+// media of removed files are deleted. The manifest mapping local paths to
+// media UUIDs is written even if some files failed.
+func (c *Client) SyncMediaDir(ctx context.Context, dir string, opts *SyncOptions) (*SyncReport, error) {
+	o := SyncOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.ManifestPath == "" {
+		o.ManifestPath = filepath.Join(dir, DefaultManifestName)
+	}
+
+	manifest, err := loadSyncManifest(o.ManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	local, err := scanSyncDir(dir, o.ManifestPath, o.Ignore)
+	if err != nil {
+		return nil, err
+	}
+	remoteList, err := c.listAllMedia(ctx, &ListMediaOptions{Folder: o.Folder})
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]Media, len(remoteList))
+	for _, m := range remoteList {
+		remote[m.UUID] = m
+	}
+
+	report := &SyncReport{Manifest: manifest}
+	var mu sync.Mutex
+	fail := func(err error) {
+		mu.Lock()
+		report.Errors = append(report.Errors, err)
+		mu.Unlock()
+	}
+
+	type upload struct {
+		file     localFile
+		previous *SyncManifestEntry
+	}
+	var uploads []upload
+	for _, f := range local {
+		entry, ok := manifest.Files[f.rel]
+		if ok {
+			m, exists := remote[entry.UUID]
+			if exists && (m.Checksum == f.checksum || m.Checksum == "" && entry.Checksum == f.checksum) {
+				report.Unchanged = append(report.Unchanged, f.rel)
+				continue
+			}
+			if exists {
+				prev := entry
+				uploads = append(uploads, upload{file: f, previous: &prev})
+				continue
+			}
+		}
+		if m, found := findSyncedMedia(remoteList, f); found {
+			manifest.Files[f.rel] = SyncManifestEntry{UUID: m.UUID, Checksum: f.checksum, Size: f.size, URL: m.URL}
+			report.Unchanged = append(report.Unchanged, f.rel)
+			continue
+		}
+		uploads = append(uploads, upload{file: f})
+	}
+
+	if o.DryRun {
+		for _, u := range uploads {
+			if u.previous != nil {
+				report.Updated = append(report.Updated, u.file.rel)
+			} else {
+				report.Uploaded = append(report.Uploaded, u.file.rel)
+			}
+		}
+		uploads = nil
+	}
+	runWorkers(ctx, sliceChan(ctx, uploads), o.Concurrency, func(u upload) {
+		m, err := c.UploadMediaFile(ctx, u.file.abs, &UploadOptions{Folder: o.Folder, Tags: o.Tags})
+		if err != nil {
+			fail(fmt.Errorf("upload %s: %w", u.file.rel, err))
+			return
+		}
+		mu.Lock()
+		manifest.Files[u.file.rel] = SyncManifestEntry{UUID: m.UUID, Checksum: u.file.checksum, Size: u.file.size, URL: m.URL}
+		if u.previous != nil {
+			report.Updated = append(report.Updated, u.file.rel)
+		} else {
+			report.Uploaded = append(report.Uploaded, u.file.rel)
+		}
+		mu.Unlock()
+		if u.previous != nil && o.Delete && ctx.Err() == nil {
+			if err := c.DeleteMedia(u.previous.UUID); err != nil {
+				fail(fmt.Errorf("delete previous version of %s: %w", u.file.rel, err))
+			}
+		}
+	})
+
+	seen := make(map[string]bool, len(local))
+	for _, f := range local {
+		seen[f.rel] = true
+	}
+	var removed []string
+	for rel := range manifest.Files {
+		if !seen[rel] {
+			removed = append(removed, rel)
+		}
+	}
+	sort.Strings(removed)
+	for _, rel := range removed {
+		if ctx.Err() != nil {
+			// Do not delete anything once the sync was cancelled.
+			break
+		}
+		if !o.Delete {
+			report.Orphaned = append(report.Orphaned, rel)
+			continue
+		}
+		if !o.DryRun {
+			if _, exists := remote[manifest.Files[rel].UUID]; exists {
+				if err := c.DeleteMedia(manifest.Files[rel].UUID); err != nil {
+					fail(fmt.Errorf("delete %s: %w", rel, err))
+					continue
+				}
+			}
+			delete(manifest.Files, rel)
+		}
+		report.Deleted = append(report.Deleted, rel)
+	}
+
+	sort.Strings(report.Uploaded)
+	sort.Strings(report.Updated)
+	sort.Strings(report.Unchanged)
+	if !o.DryRun {
+		if err := manifest.save(o.ManifestPath); err != nil {
+			return report, err
+		}
+	}
+	return report, ctx.Err()
+}
+
+// findSyncedMedia looks for a library file identical to f, so that files
+// uploaded before the manifest existed are not uploaded again.
+func findSyncedMedia(remote []Media, f localFile) (Media, bool) {
+	for _, m := range remote {
+		if m.Checksum == f.checksum && m.Filename == path.Base(f.rel) {
+			return m, true
+		}
+	}
+	return Media{}, false
+}
+
+func scanSyncDir(dir, manifestPath string, ignore []string) ([]localFile, error) {
+	manifestAbs, _ := filepath.Abs(manifestPath)
+	var files []localFile
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if abs, _ := filepath.Abs(p); abs == manifestAbs {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range ignore {
+			if ok, _ := path.Match(pattern, rel); ok {
+				return nil
+			}
+			if ok, _ := path.Match(pattern, d.Name()); ok {
+				return nil
+			}
+		}
+		sum, size, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, localFile{rel: rel, abs: p, checksum: sum, size: size})
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func loadSyncManifest(p string) (*SyncManifest, error) {
+	manifest := &SyncManifest{Files: make(map[string]SyncManifestEntry)}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest %s: %w", p, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]SyncManifestEntry)
+	}
+	return manifest, nil
+}
+
+// save atomically writes the manifest to p.
+func (m *SyncManifest) save(p string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
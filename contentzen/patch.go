@@ -0,0 +1,483 @@
+package contentzen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Patch is a partial document update sent with PatchDocument.
+type Patch interface {
+	// ContentType is the media type the patch is sent as.
+	ContentType() string
+	// Apply returns a copy of doc with the patch applied.
+	Apply(doc *Document) (*Document, error)
+}
+
+// ErrPatchTestFailed is returned by JSONPatch.Apply when a "test"
+// operation does not match the document. The API answers a failed test
+// with the same 409 as a concurrent change, so PatchDocument reports it as
+// a ConflictError instead.
+var ErrPatchTestFailed = errors.New("patch test failed")
+
+// PatchDocument applies a partial update to a document and returns the
+// updated document (requires API token). Use MergePatch, JSONPatch or
+// FieldMask to build the patch.
+func (c *Client) PatchDocument(collectionUUID, documentUUID string, patch Patch) (*Document, error) {
+	return c.PatchDocumentVersion(collectionUUID, documentUUID, 0, patch)
+}
+
+// PatchDocumentVersion is like PatchDocument but only applies the patch if
+// the document is still at version, sent as If-Match. It returns an error
+// matching ErrConflict if the document was changed since or a "test"
+// operation of a JSONPatch failed; the API does not tell the two apart. A
+// version of zero patches unconditionally.
+func (c *Client) PatchDocumentVersion(collectionUUID, documentUUID string, version int64, patch Patch) (*Document, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/documents/%s/%s", c.BaseURL, collectionUUID, documentUUID)
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("PATCH", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", patch.ContentType())
+	if version > 0 {
+		req.Header.Set("If-Match", ETag(version))
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return nil, &ConflictError{Kind: "document", UUID: documentUUID, Version: version}
+	default:
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var patched Document
+	if err := json.NewDecoder(resp.Body).Decode(&patched); err != nil {
+		return nil, err
+	}
+	return &patched, nil
+}
+
+// MergePatch is a JSON Merge Patch (RFC 7386) of the JSON form of a
+// document. Objects are merged recursively and keys set to nil are
+// removed, for example:
+//
+//	contentzen.MergePatch{"payload": map[string]interface{}{"title": "New title", "subtitle": nil}}
+type MergePatch map[string]interface{}
+
+// ContentType implements Patch.
+func (MergePatch) ContentType() string { return "application/merge-patch+json" }
+
+// Apply implements Patch.
+func (p MergePatch) Apply(doc *Document) (*Document, error) {
+	return applyToJSON(doc, func(v interface{}) (interface{}, error) {
+		return mergePatch(v, normalizeJSON(map[string]interface{}(p))), nil
+	})
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// PatchOperation is a single JSON Patch operation.
+type PatchOperation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy" or "test".
+	Op string `json:"op"`
+	// Path is a JSON Pointer into the document, such as "/payload/tags/0".
+	Path string `json:"path"`
+	// From is the source pointer of "move" and "copy".
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON always includes the value of operations that take one, even
+// if it is nil.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{o.Op, o.Path, o.Value})
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}{o.Op, o.Path, o.From})
+}
+
+// JSONPatch is a JSON Patch (RFC 6902) of the JSON form of a document. The
+// operations are applied in order and the patch fails as a whole if any of
+// them fails. Its methods return extended copies:
+//
+//	patch := contentzen.JSONPatch{}.
+//		Test("/payload/title", "Old title").
+//		Replace("/payload/title", "New title").
+//		Add("/payload/tags/-", "news")
+type JSONPatch []PatchOperation
+
+// ContentType implements Patch.
+func (JSONPatch) ContentType() string { return "application/json-patch+json" }
+
+// Add adds value at path, inserting it if path names an array index and
+// appending it if the index is "-".
+func (p JSONPatch) Add(path string, value interface{}) JSONPatch {
+	return append(p[:len(p):len(p)], PatchOperation{Op: "add", Path: path, Value: value})
+}
+
+// Remove removes the value at path.
+func (p JSONPatch) Remove(path string) JSONPatch {
+	return append(p[:len(p):len(p)], PatchOperation{Op: "remove", Path: path})
+}
+
+// Replace replaces the existing value at path.
+func (p JSONPatch) Replace(path string, value interface{}) JSONPatch {
+	return append(p[:len(p):len(p)], PatchOperation{Op: "replace", Path: path, Value: value})
+}
+
+// Move moves the value at from to path.
+func (p JSONPatch) Move(from, path string) JSONPatch {
+	return append(p[:len(p):len(p)], PatchOperation{Op: "move", Path: path, From: from})
+}
+
+// Copy copies the value at from to path.
+func (p JSONPatch) Copy(from, path string) JSONPatch {
+	return append(p[:len(p):len(p)], PatchOperation{Op: "copy", Path: path, From: from})
+}
+
+// Test makes the patch fail unless the value at path equals value: Apply
+// returns ErrPatchTestFailed and PatchDocument a ConflictError.
+func (p JSONPatch) Test(path string, value interface{}) JSONPatch {
+	return append(p[:len(p):len(p)], PatchOperation{Op: "test", Path: path, Value: value})
+}
+
+// Apply implements Patch.
+func (p JSONPatch) Apply(doc *Document) (*Document, error) {
+	return applyToJSON(doc, func(v interface{}) (interface{}, error) {
+		var err error
+		for i, op := range p {
+			if v, err = applyOperation(v, op); err != nil {
+				return nil, fmt.Errorf("json patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		}
+		return v, nil
+	})
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add":
+		return addValue(doc, path, normalizeJSON(op.Value))
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		if _, err := getValue(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return normalizeJSON(op.Value), nil
+		}
+		return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+			return setChild(parent, key, normalizeJSON(op.Value))
+		})
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		doc, value, err := removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, normalizeJSON(value))
+	case "test":
+		value, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSON(value, op.Value) {
+			return nil, ErrPatchTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		var err error
+		if doc, err = child(doc, key); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			if key == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(key, len(p)+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("cannot add %q to a %s", key, jsonKind(parent))
+	})
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err := updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		var err error
+		if removed, err = child(parent, key); err != nil {
+			return nil, err
+		}
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			i, _ := arrayIndex(key, len(p))
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return parent, nil
+	})
+	return doc, removed, err
+}
+
+// updateParent replaces the container holding the last token of path with
+// the result of fn, rebuilding the containers above it.
+func updateParent(doc interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	next, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := updateParent(next, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	return setChild(doc, path[0], updated)
+}
+
+func child(doc interface{}, key string) (interface{}, error) {
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		v, ok := d[key]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", key)
+		}
+		return v, nil
+	case []interface{}:
+		i, err := arrayIndex(key, len(d))
+		if err != nil {
+			return nil, err
+		}
+		return d[i], nil
+	}
+	return nil, fmt.Errorf("cannot index a %s with %q", jsonKind(doc), key)
+}
+
+func setChild(doc interface{}, key string, value interface{}) (interface{}, error) {
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		d[key] = value
+		return d, nil
+	case []interface{}:
+		i, err := arrayIndex(key, len(d))
+		if err != nil {
+			return nil, err
+		}
+		d[i] = value
+		return d, nil
+	}
+	return nil, fmt.Errorf("cannot index a %s with %q", jsonKind(doc), key)
+}
+
+// arrayIndex parses an array index token that must be below n.
+func arrayIndex(key string, n int) (int, error) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || (len(key) > 1 && key[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", key)
+	}
+	if i >= n {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// FieldMask is a Patch that sets and unsets payload fields addressed by
+// dotted paths such as "seo.title". Objects on the way to a field are
+// created as needed. It is sent as a JSON Merge Patch, so setting a field
+// to nil unsets it.
+//
+//	mask := contentzen.NewFieldMask().Set("seo.title", "Hello").Unset("draft_notes")
+type FieldMask struct {
+	patch MergePatch
+}
+
+// NewFieldMask returns an empty field mask.
+func NewFieldMask() *FieldMask {
+	return &FieldMask{patch: MergePatch{}}
+}
+
+// Set sets the payload field at path to value.
+func (m *FieldMask) Set(path string, value interface{}) *FieldMask {
+	fields := strings.Split(path, ".")
+	payload, _ := m.patch["payload"].(map[string]interface{})
+	if payload == nil {
+		payload = make(map[string]interface{})
+		m.patch["payload"] = payload
+	}
+	obj := payload
+	for _, f := range fields[:len(fields)-1] {
+		next, ok := obj[f].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			obj[f] = next
+		}
+		obj = next
+	}
+	obj[fields[len(fields)-1]] = value
+	return m
+}
+
+// Unset removes the payload field at path.
+func (m *FieldMask) Unset(path string) *FieldMask {
+	return m.Set(path, nil)
+}
+
+// ContentType implements Patch.
+func (m *FieldMask) ContentType() string { return m.patch.ContentType() }
+
+// Apply implements Patch.
+func (m *FieldMask) Apply(doc *Document) (*Document, error) { return m.patch.Apply(doc) }
+
+// MarshalJSON encodes the mask as a JSON Merge Patch.
+func (m *FieldMask) MarshalJSON() ([]byte, error) { return json.Marshal(m.patch) }
+
+// applyToJSON applies a patch to the JSON form of doc and decodes the
+// result back into a new document. A nil payload is treated as empty so
+// that fields can be added to it.
+func applyToJSON(doc *Document, apply func(interface{}) (interface{}, error)) (*Document, error) {
+	if doc.Payload == nil {
+		withPayload := *doc
+		withPayload.Payload = map[string]interface{}{}
+		doc = &withPayload
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	if v, err = apply(v); err != nil {
+		return nil, err
+	}
+	if b, err = json.Marshal(v); err != nil {
+		return nil, err
+	}
+	var patched Document
+	if err := json.Unmarshal(b, &patched); err != nil {
+		return nil, fmt.Errorf("patched document is invalid: %w", err)
+	}
+	return &patched, nil
+}
@@ -2,21 +2,23 @@ package contentzen
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
-	"os"
 )
 
 // GetDocuments fetches documents from a collection (requires API token).
 func (c *Client) GetDocuments(collectionUUID string) ([]Document, error) {
+	return c.getDocuments(context.Background(), collectionUUID)
+}
+
+func (c *Client) getDocuments(ctx context.Context, collectionUUID string) ([]Document, error) {
 	if c.APIToken == "" {
 		return nil, fmt.Errorf("API token required for this endpoint")
 	}
 	url := fmt.Sprintf("%s/api/v1/documents/%s", c.BaseURL, collectionUUID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +38,42 @@ func (c *Client) GetDocuments(collectionUUID string) ([]Document, error) {
 	return docs, nil
 }
 
+// GetDocument fetches a single document, whatever its state (requires API token).
+func (c *Client) GetDocument(collectionUUID, documentUUID string) (*Document, error) {
+	return c.getDocument(context.Background(), collectionUUID, documentUUID)
+}
+
+func (c *Client) getDocument(ctx context.Context, collectionUUID, documentUUID string) (*Document, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/documents/%s/%s", c.BaseURL, collectionUUID, documentUUID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var doc Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
 // CreateDocument creates a new document in a collection (requires API token).
 func (c *Client) CreateDocument(collectionUUID string, doc *Document) (*Document, error) {
+	return c.createDocument(context.Background(), collectionUUID, doc)
+}
+
+func (c *Client) createDocument(ctx context.Context, collectionUUID string, doc *Document) (*Document, error) {
 	if c.APIToken == "" {
 		return nil, fmt.Errorf("API token required for this endpoint")
 	}
@@ -46,7 +82,7 @@ func (c *Client) CreateDocument(collectionUUID string, doc *Document) (*Document
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -67,8 +103,15 @@ func (c *Client) CreateDocument(collectionUUID string, doc *Document) (*Document
 	return &created, nil
 }
 
-// UpdateDocument updates an existing document (requires API token).
+// UpdateDocument updates an existing document (requires API token). If
+// doc.Version is set, the update only succeeds if the document has not
+// been changed since that version was read; otherwise a *ConflictError is
+// returned.
 func (c *Client) UpdateDocument(collectionUUID, documentUUID string, doc *Document) (*Document, error) {
+	return c.updateDocument(context.Background(), collectionUUID, documentUUID, doc)
+}
+
+func (c *Client) updateDocument(ctx context.Context, collectionUUID, documentUUID string, doc *Document) (*Document, error) {
 	if c.APIToken == "" {
 		return nil, fmt.Errorf("API token required for this endpoint")
 	}
@@ -77,17 +120,23 @@ func (c *Client) UpdateDocument(collectionUUID, documentUUID string, doc *Docume
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.APIToken)
 	req.Header.Set("Content-Type", "application/json")
+	if doc.Version > 0 {
+		req.Header.Set("If-Match", ETag(doc.Version))
+	}
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, &ConflictError{Kind: "document", UUID: documentUUID, Version: doc.Version}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
 	}
@@ -100,11 +149,15 @@ func (c *Client) UpdateDocument(collectionUUID, documentUUID string, doc *Docume
 
 // DeleteDocument deletes a document (requires API token).
 func (c *Client) DeleteDocument(collectionUUID, documentUUID string) error {
+	return c.deleteDocument(context.Background(), collectionUUID, documentUUID)
+}
+
+func (c *Client) deleteDocument(ctx context.Context, collectionUUID, documentUUID string) error {
 	if c.APIToken == "" {
 		return fmt.Errorf("API token required for this endpoint")
 	}
 	url := fmt.Sprintf("%s/api/v1/documents/%s/%s", c.BaseURL, collectionUUID, documentUUID)
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return err
 	}
@@ -148,11 +201,15 @@ func (c *Client) GetCollections() ([]Collection, error) {
 
 // GetCollection fetches a specific collection by UUID.
 func (c *Client) GetCollection(collectionUUID string) (*Collection, error) {
+	return c.getCollection(context.Background(), collectionUUID)
+}
+
+func (c *Client) getCollection(ctx context.Context, collectionUUID string) (*Collection, error) {
 	if c.APIToken == "" {
 		return nil, fmt.Errorf("API token required for this endpoint")
 	}
 	url := fmt.Sprintf("%s/api/v1/collections/%s", c.BaseURL, collectionUUID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +260,9 @@ func (c *Client) CreateCollection(col *Collection) (*Collection, error) {
 	return &created, nil
 }
 
-// UpdateCollection updates an existing collection.
+// UpdateCollection updates an existing collection. If col.Version is set,
+// the update fails with a *ConflictError if the collection has been changed
+// since that version was read.
 func (c *Client) UpdateCollection(collectionUUID string, col *Collection) (*Collection, error) {
 	if c.APIToken == "" {
 		return nil, fmt.Errorf("API token required for this endpoint")
@@ -219,11 +278,17 @@ func (c *Client) UpdateCollection(collectionUUID string, col *Collection) (*Coll
 	}
 	req.Header.Set("Authorization", "Bearer "+c.APIToken)
 	req.Header.Set("Content-Type", "application/json")
+	if col.Version > 0 {
+		req.Header.Set("If-Match", ETag(col.Version))
+	}
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, &ConflictError{Kind: "collection", UUID: collectionUUID, Version: col.Version}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
 	}
@@ -284,46 +349,7 @@ func (c *Client) ListMedia() ([]Media, error) {
 
 // UploadMedia uploads a media file. Accepts a file path.
 func (c *Client) UploadMedia(filePath string) (*Media, error) {
-	if c.APIToken == "" {
-		return nil, fmt.Errorf("API token required for this endpoint")
-	}
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
-	fw, err := w.CreateFormFile("file", file.Name())
-	if err != nil {
-		return nil, err
-	}
-	if _, err = io.Copy(fw, file); err != nil {
-		return nil, err
-	}
-	w.Close()
-
-	url := fmt.Sprintf("%s/api/v1/media/upload", c.BaseURL)
-	req, err := http.NewRequest("POST", url, &b)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.APIToken)
-	req.Header.Set("Content-Type", w.FormDataContentType())
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	var media Media
-	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
-		return nil, err
-	}
-	return &media, nil
+	return c.UploadMediaFile(context.Background(), filePath, nil)
 }
 
 // GetMedia fetches a specific media file by UUID.
@@ -407,30 +433,7 @@ func (c *Client) DeleteMedia(mediaUUID string) error {
 
 // DownloadMedia downloads a media file by UUID to the specified destination path.
 func (c *Client) DownloadMedia(mediaUUID, destPath string) error {
-	if c.APIToken == "" {
-		return fmt.Errorf("API token required for this endpoint")
-	}
-	url := fmt.Sprintf("%s/api/v1/media/%s/download", c.BaseURL, mediaUUID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.APIToken)
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	out, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	_, err = io.Copy(out, resp.Body)
-	return err
+	return c.DownloadMediaFile(context.Background(), mediaUUID, destPath, nil)
 }
 
 // ListWebhooks fetches all webhooks for the authenticated project.
@@ -571,11 +574,15 @@ func (c *Client) GetCollectionSchema(collectionUUID string) (map[string]interfac
 
 // GetCollectionFields fetches the fields for a collection.
 func (c *Client) GetCollectionFields(collectionUUID string) ([]CollectionField, error) {
+	return c.getCollectionFields(context.Background(), collectionUUID)
+}
+
+func (c *Client) getCollectionFields(ctx context.Context, collectionUUID string) ([]CollectionField, error) {
 	if c.APIToken == "" {
 		return nil, fmt.Errorf("API token required for this endpoint")
 	}
 	url := fmt.Sprintf("%s/api/v1/collections/%s/fields", c.BaseURL, collectionUUID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,135 @@
+package contentzen
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// ProgressInterval is the minimum time between two progress reports for
+// the same transfer. The final report is always delivered.
+var ProgressInterval = 100 * time.Millisecond
+
+// Progress describes the state of an upload or download.
+type Progress struct {
+	// Transferred is the number of bytes transferred so far.
+	Transferred int64 `json:"transferred"`
+	// Total is the total number of bytes, or -1 if unknown.
+	Total int64 `json:"total"`
+	// Elapsed is the time since the transfer started.
+	Elapsed time.Duration `json:"elapsed"`
+	// BytesPerSecond is the average throughput since the transfer started.
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	// Done is set on the final report, once all bytes have been transferred.
+	Done bool `json:"done"`
+}
+
+// Percent returns the completed percentage, or -1 if the total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Transferred) / float64(p.Total) * 100
+}
+
+// ProgressFunc receives progress reports. It is called from the goroutine
+// performing the transfer and should return quickly.
+type ProgressFunc func(Progress)
+
+// ProgressChan returns a ProgressFunc that sends reports on ch without
+// blocking the transfer. Reports are dropped while ch is full, except the
+// final one: if ch has no room for it, it is sent from a new goroutine once
+// ch has room. That goroutine only exits when the report is received, so
+// the caller must keep receiving from ch until it gets the report with Done
+// set, or use a buffered ch that always has room for it.
+func ProgressChan(ch chan<- Progress) ProgressFunc {
+	return func(p Progress) {
+		select {
+		case ch <- p:
+		default:
+			if p.Done {
+				go func() { ch <- p }()
+			}
+		}
+	}
+}
+
+// progressTracker accumulates transferred bytes, possibly from several
+// goroutines, and reports them to fn at most every ProgressInterval.
+type progressTracker struct {
+	fn    ProgressFunc
+	total int64
+
+	mu          sync.Mutex
+	start       time.Time
+	last        time.Time
+	transferred int64
+	resumed     int64
+	finished    bool
+}
+
+func newProgressTracker(total int64, fn ProgressFunc) *progressTracker {
+	now := time.Now()
+	return &progressTracker{fn: fn, total: total, start: now, last: now}
+}
+
+// resume records n bytes transferred by an earlier attempt. They count
+// towards Transferred but not towards the throughput.
+func (t *progressTracker) resume(n int64) {
+	t.mu.Lock()
+	t.transferred += n
+	t.resumed += n
+	t.mu.Unlock()
+}
+
+// add records n more bytes; n may be negative when a failed chunk is
+// retried. done forces a final report.
+func (t *progressTracker) add(n int64, done bool) {
+	t.mu.Lock()
+	t.transferred += n
+	now := time.Now()
+	report := !t.finished && (done || now.Sub(t.last) >= ProgressInterval)
+	t.finished = t.finished || done
+	if report {
+		t.last = now
+	}
+	elapsed := now.Sub(t.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(t.transferred-t.resumed) / elapsed.Seconds()
+	}
+	p := Progress{
+		Transferred:    t.transferred,
+		Total:          t.total,
+		Elapsed:        elapsed,
+		BytesPerSecond: rate,
+		Done:           done,
+	}
+	t.mu.Unlock()
+	if report {
+		t.fn(p)
+	}
+}
+
+// progressReader reports the bytes read through it to a tracker. If
+// final is set, reaching EOF completes the transfer.
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+	final   bool
+	read    int64
+}
+
+func newProgressReader(r io.Reader, total int64, fn ProgressFunc) *progressReader {
+	return &progressReader{r: r, tracker: newProgressTracker(total, fn), final: true}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	done := p.final && err == io.EOF
+	if n > 0 || done {
+		p.tracker.add(int64(n), done)
+	}
+	return n, err
+}
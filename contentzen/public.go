@@ -1,6 +1,7 @@
 package contentzen
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,8 +9,16 @@ import (
 
 // GetPublicDocuments fetches all published documents from a public collection.
 func (c *Client) GetPublicDocuments(collectionUUID string) ([]Document, error) {
+	return c.getPublicDocuments(context.Background(), collectionUUID)
+}
+
+func (c *Client) getPublicDocuments(ctx context.Context, collectionUUID string) ([]Document, error) {
 	url := fmt.Sprintf("%s/api/v1/documents/collection/%s?state=published", c.BaseURL, collectionUUID)
-	resp, err := c.HTTPClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
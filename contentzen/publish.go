@@ -0,0 +1,163 @@
+package contentzen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrInvalidTransition matches a *TransitionError with errors.Is.
+var ErrInvalidTransition = errors.New("invalid document state transition")
+
+// TransitionError is returned when a document cannot move from its current
+// state to the requested one.
+type TransitionError struct {
+	From DocumentState
+	To   DocumentState
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change document state from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool { return target == ErrInvalidTransition }
+
+// transitions lists the states each state may move to.
+var transitions = map[DocumentState][]DocumentState{
+	StateDraft:     {StatePublished, StateArchived},
+	StatePublished: {StateDraft, StateArchived},
+	StateArchived:  {StateDraft},
+}
+
+// CanTransition reports whether a document in state from may be moved to
+// state to. An empty state is treated as StateDraft, and moving to the
+// current state is not a transition.
+func CanTransition(from, to DocumentState) bool {
+	if from == "" {
+		from = StateDraft
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishDocument publishes a draft document (requires API token).
+// Publishing a published document returns it unchanged.
+func (c *Client) PublishDocument(collectionUUID, documentUUID string) (*Document, error) {
+	return c.transitionDocument(collectionUUID, documentUUID, "publish", "", StatePublished)
+}
+
+// UnpublishDocument moves a published document back to draft (requires API
+// token). Unpublishing a draft returns it unchanged; archived documents
+// are restored with UnarchiveDocument.
+func (c *Client) UnpublishDocument(collectionUUID, documentUUID string) (*Document, error) {
+	return c.transitionDocument(collectionUUID, documentUUID, "unpublish", StatePublished, StateDraft)
+}
+
+// ArchiveDocument archives a draft or published document (requires API
+// token). Archiving an archived document returns it unchanged.
+func (c *Client) ArchiveDocument(collectionUUID, documentUUID string) (*Document, error) {
+	return c.transitionDocument(collectionUUID, documentUUID, "archive", "", StateArchived)
+}
+
+// UnarchiveDocument moves an archived document back to draft (requires API
+// token). Unarchiving a draft returns it unchanged.
+func (c *Client) UnarchiveDocument(collectionUUID, documentUUID string) (*Document, error) {
+	return c.transitionDocument(collectionUUID, documentUUID, "unarchive", StateArchived, StateDraft)
+}
+
+// SchedulePublish schedules a draft document to be published at the given
+// time (requires API token). A zero time cancels a scheduled publish.
+func (c *Client) SchedulePublish(collectionUUID, documentUUID string, at time.Time) (*Document, error) {
+	return c.scheduleDocument(collectionUUID, documentUUID, "publish_at", at, StatePublished)
+}
+
+// ScheduleUnpublish schedules a published document, or one scheduled to be
+// published, to be moved back to draft at the given time (requires API
+// token). A zero time cancels a scheduled unpublish.
+func (c *Client) ScheduleUnpublish(collectionUUID, documentUUID string, at time.Time) (*Document, error) {
+	return c.scheduleDocument(collectionUUID, documentUUID, "unpublish_at", at, StateDraft)
+}
+
+// transitionDocument checks that the document may move to state to and
+// asks the server to perform action. If from is set, the document must be
+// in that state. A document already in state to is returned unchanged.
+func (c *Client) transitionDocument(collectionUUID, documentUUID, action string, from, to DocumentState) (*Document, error) {
+	current, err := c.GetDocument(collectionUUID, documentUUID)
+	if err != nil {
+		return nil, err
+	}
+	state := DocumentState(current.State)
+	if state == "" {
+		state = StateDraft
+	}
+	if state == to {
+		return current, nil
+	}
+	if from != "" && state != from || !CanTransition(state, to) {
+		return nil, &TransitionError{From: DocumentState(current.State), To: to}
+	}
+	url := fmt.Sprintf("%s/api/v1/documents/%s/%s/%s", c.BaseURL, collectionUUID, documentUUID, action)
+	return c.postDocumentAction(url, nil, DocumentState(current.State), to)
+}
+
+// scheduleDocument sets the schedule field to at, after checking that the
+// document will be able to move to state to by then.
+func (c *Client) scheduleDocument(collectionUUID, documentUUID, field string, at time.Time, to DocumentState) (*Document, error) {
+	current, err := c.GetDocument(collectionUUID, documentUUID)
+	if err != nil {
+		return nil, err
+	}
+	from := DocumentState(current.State)
+	if to == StateDraft && !current.PublishAt.IsZero() {
+		from = StatePublished
+	}
+	if !at.IsZero() && (!CanTransition(from, to) || to == StateDraft && from != StatePublished) {
+		return nil, &TransitionError{From: DocumentState(current.State), To: to}
+	}
+	var value interface{}
+	if !at.IsZero() {
+		value = at.UTC().Format(time.RFC3339)
+	}
+	body, err := json.Marshal(map[string]interface{}{field: value})
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/v1/documents/%s/%s/schedule", c.BaseURL, collectionUUID, documentUUID)
+	return c.postDocumentAction(url, body, DocumentState(current.State), to)
+}
+
+// postDocumentAction posts body to a document action endpoint. A 409
+// response means the document's state changed since it was checked.
+func (c *Client) postDocumentAction(url string, body []byte, from, to DocumentState) (*Document, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return nil, &TransitionError{From: from, To: to}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var doc Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
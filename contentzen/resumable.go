@@ -0,0 +1,513 @@
+package contentzen
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for resumable uploads.
+const (
+	DefaultPartSize        = 8 << 20
+	DefaultUploadThreshold = 16 << 20
+)
+
+// ResumableUploadOptions configures UploadMediaResumable.
+type ResumableUploadOptions struct {
+	UploadOptions
+	// PartSize is the size of each uploaded part. Defaults to DefaultPartSize.
+	PartSize int64
+	// Threshold is the file size below which the file is sent in a single
+	// request. Defaults to DefaultUploadThreshold.
+	Threshold int64
+	// Concurrency is the number of parts uploaded in parallel. Defaults to 4.
+	Concurrency int
+	// MaxRetries is the number of times a failed part is retried. Defaults to 3.
+	MaxRetries int
+	// StatePath is where the upload state is persisted so that a restarted
+	// process can resume. Defaults to a file named after the absolute path
+	// of the uploaded file in the "contentzen/uploads" directory of the
+	// user cache directory, or of the temporary directory if there is none.
+	StatePath string
+}
+
+// MultipartUpload is an upload session created by InitiateUpload.
+type MultipartUpload struct {
+	UploadID string         `json:"upload_id"`
+	PartSize int64          `json:"part_size"`
+	Parts    []UploadedPart `json:"parts,omitempty"`
+}
+
+// UploadedPart identifies a part stored by the server.
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size,omitempty"`
+}
+
+// UploadState is the persisted progress of a resumable upload.
+type UploadState struct {
+	UploadID string         `json:"upload_id"`
+	FilePath string         `json:"file_path"`
+	Size     int64          `json:"size"`
+	ModTime  time.Time      `json:"mod_time"`
+	PartSize int64          `json:"part_size"`
+	Parts    map[int]string `json:"parts"`
+}
+
+// InitiateUpload starts a multipart upload of size bytes (requires API token).
+func (c *Client) InitiateUpload(ctx context.Context, filename, contentType string, size, partSize int64) (*MultipartUpload, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/uploads", c.BaseURL)
+	body, err := json.Marshal(map[string]interface{}{
+		"filename":     filename,
+		"content_type": contentType,
+		"size":         size,
+		"part_size":    partSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var upload MultipartUpload
+	if err := json.NewDecoder(resp.Body).Decode(&upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// GetUpload fetches a multipart upload and the parts stored so far.
+func (c *Client) GetUpload(ctx context.Context, uploadID string) (*MultipartUpload, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	upload, err := c.findUpload(ctx, uploadID)
+	if err == nil && upload == nil {
+		return nil, fmt.Errorf("unexpected status: %d %s", http.StatusNotFound, http.StatusText(http.StatusNotFound))
+	}
+	return upload, err
+}
+
+// findUpload fetches a multipart upload, returning nil without an error if
+// it does not exist.
+func (c *Client) findUpload(ctx context.Context, uploadID string) (*MultipartUpload, error) {
+	url := fmt.Sprintf("%s/api/v1/uploads/%s", c.BaseURL, uploadID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var upload MultipartUpload
+	if err := json.NewDecoder(resp.Body).Decode(&upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// UploadPart uploads part number partNumber (starting at 1) of a multipart
+// upload, reading size bytes from r.
+func (c *Client) UploadPart(ctx context.Context, uploadID string, partNumber int, r io.Reader, size int64) (*UploadedPart, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/uploads/%s/parts/%d", c.BaseURL, uploadID, partNumber)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, &partStatusError{code: resp.StatusCode, status: resp.Status}
+	}
+	var part UploadedPart
+	if err := json.NewDecoder(resp.Body).Decode(&part); err != nil {
+		return nil, err
+	}
+	return &part, nil
+}
+
+// CompleteUpload assembles the uploaded parts into a media file.
+func (c *Client) CompleteUpload(ctx context.Context, uploadID string, parts []UploadedPart, opts *UploadOptions) (*Media, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	url := fmt.Sprintf("%s/api/v1/uploads/%s/complete", c.BaseURL, uploadID)
+	body, err := json.Marshal(map[string]interface{}{
+		"parts":    parts,
+		"alt_text": opts.AltText,
+		"tags":     opts.Tags,
+		"folder":   opts.Folder,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var media Media
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// AbortUpload discards a multipart upload and its parts.
+func (c *Client) AbortUpload(ctx context.Context, uploadID string) error {
+	if c.APIToken == "" {
+		return fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/uploads/%s", c.BaseURL, uploadID)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// UploadMediaResumable uploads the file at filePath in parts, retrying
+// parts that fail with a network or server error and persisting its
+// progress to opts.StatePath. If the state file of an earlier, interrupted
+// upload of the same unmodified file exists and the server still has the
+// upload, only the missing parts are sent. Files smaller than
+// opts.Threshold are sent in a single request instead. The upload policy
+// is checked before every attempt, including resumed ones.
+func (c *Client) UploadMediaResumable(ctx context.Context, filePath string, opts *ResumableUploadOptions) (*Media, error) {
+	o := ResumableUploadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.PartSize <= 0 {
+		o.PartSize = DefaultPartSize
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultUploadThreshold
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+	if o.StatePath == "" {
+		path, err := defaultUploadStatePath(filePath)
+		if err != nil {
+			return nil, err
+		}
+		o.StatePath = path
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < o.Threshold {
+		return c.UploadMediaFile(ctx, filePath, &o.UploadOptions)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	policy := o.Policy
+	if policy == nil {
+		policy = c.UploadPolicy
+	}
+	_, contentType, err := inspectUpload(file, filepath.Base(filePath), "", info.Size(), policy)
+	if err != nil {
+		return nil, err
+	}
+	state, err := c.resumeUpload(ctx, o.StatePath, info)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		upload, err := c.InitiateUpload(ctx, filepath.Base(filePath), contentType, info.Size(), o.PartSize)
+		if err != nil {
+			return nil, err
+		}
+		partSize := upload.PartSize
+		if partSize <= 0 {
+			partSize = o.PartSize
+		}
+		state = &UploadState{
+			UploadID: upload.UploadID,
+			FilePath: filePath,
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+			PartSize: partSize,
+			Parts:    make(map[int]string),
+		}
+		if err := state.save(o.StatePath); err != nil {
+			return nil, err
+		}
+	}
+
+	numParts := int((state.Size + state.PartSize - 1) / state.PartSize)
+	var missing []int
+	for n := 1; n <= numParts; n++ {
+		if _, ok := state.Parts[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	var tracker *progressTracker
+	if o.Progress != nil {
+		tracker = newProgressTracker(state.Size, o.Progress)
+		var done int64
+		for n := range state.Parts {
+			done += partLength(state, n)
+		}
+		tracker.resume(done)
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	parts := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < o.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range parts {
+				etag, err := c.uploadPartWithRetry(ctx, file, state, n, o.MaxRetries, tracker)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					state.Parts[n] = etag
+					if err := state.save(o.StatePath); err != nil && firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, n := range missing {
+		select {
+		case parts <- n:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(parts)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	completed := make([]UploadedPart, 0, len(state.Parts))
+	for n, etag := range state.Parts {
+		completed = append(completed, UploadedPart{PartNumber: n, ETag: etag})
+	}
+	sort.Slice(completed, func(i, j int) bool { return completed[i].PartNumber < completed[j].PartNumber })
+	media, err := c.CompleteUpload(ctx, state.UploadID, completed, &o.UploadOptions)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(o.StatePath)
+	if tracker != nil {
+		tracker.add(0, true)
+	}
+	return media, nil
+}
+
+// resumeUpload loads the state at path if it belongs to an unmodified
+// file and the server still knows the upload. The server's list of parts
+// takes precedence over the persisted one. It returns nil without an error
+// if the upload has to be restarted.
+func (c *Client) resumeUpload(ctx context.Context, path string, info os.FileInfo) (*UploadState, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	var state UploadState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, nil
+	}
+	if state.Size != info.Size() || !state.ModTime.Equal(info.ModTime()) || state.PartSize <= 0 {
+		return nil, nil
+	}
+	upload, err := c.findUpload(ctx, state.UploadID)
+	if err != nil || upload == nil {
+		return nil, err
+	}
+	state.Parts = make(map[int]string, len(upload.Parts))
+	for _, p := range upload.Parts {
+		state.Parts[p.PartNumber] = p.ETag
+	}
+	return &state, nil
+}
+
+func (c *Client) uploadPartWithRetry(ctx context.Context, file *os.File, state *UploadState, n, maxRetries int, tracker *progressTracker) (string, error) {
+	size := partLength(state, n)
+	backoff := 500 * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		var r io.Reader = io.NewSectionReader(file, int64(n-1)*state.PartSize, size)
+		var pr *progressReader
+		if tracker != nil {
+			pr = &progressReader{r: r, tracker: tracker}
+			r = pr
+		}
+		var part *UploadedPart
+		part, err = c.UploadPart(ctx, state.UploadID, n, r, size)
+		if err == nil {
+			return part.ETag, nil
+		}
+		if pr != nil {
+			tracker.add(-pr.read, false)
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return "", err
+		}
+		var se *partStatusError
+		if errors.As(err, &se) && !se.retryable() {
+			return "", fmt.Errorf("upload part %d: %w", n, err)
+		}
+	}
+	return "", fmt.Errorf("upload part %d: %w", n, err)
+}
+
+// partStatusError is returned by UploadPart for an unexpected response.
+type partStatusError struct {
+	code   int
+	status string
+}
+
+func (e *partStatusError) Error() string {
+	return fmt.Sprintf("unexpected status: %s", e.status)
+}
+
+// retryable reports whether the part may succeed if sent again: client
+// errors other than timeouts and rate limiting are permanent.
+func (e *partStatusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusRequestTimeout || e.code == http.StatusTooManyRequests
+}
+
+// defaultUploadStatePath returns the state file of uploads of filePath in
+// the user cache directory.
+func defaultUploadStatePath(filePath string) (string, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, "contentzen", "uploads", hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// partLength returns the size of part n of the upload.
+func partLength(state *UploadState, n int) int64 {
+	offset := int64(n-1) * state.PartSize
+	if remaining := state.Size - offset; remaining < state.PartSize {
+		return remaining
+	}
+	return state.PartSize
+}
+
+// save atomically writes the state to path, creating its directory if
+// needed.
+func (s *UploadState) save(path string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,116 @@
+package contentzen
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Revision is a saved version of a document. A revision is recorded each
+// time a document is created, updated or restored.
+type Revision struct {
+	// Number identifies the revision within its document, starting at 1.
+	Number int `json:"number"`
+	// Author is the user or API token that made the change.
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	// Document is the document as it was saved in this revision.
+	Document Document `json:"document"`
+}
+
+// ListRevisions fetches the revisions of a document, oldest first
+// (requires API token).
+func (c *Client) ListRevisions(collectionUUID, documentUUID string) ([]Revision, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/revisions/%s/%s", c.BaseURL, collectionUUID, documentUUID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var revisions []Revision
+	if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetRevision fetches a single revision of a document (requires API token).
+func (c *Client) GetRevision(collectionUUID, documentUUID string, number int) (*Revision, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/revisions/%s/%s/%d", c.BaseURL, collectionUUID, documentUUID, number)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var revision Revision
+	if err := json.NewDecoder(resp.Body).Decode(&revision); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// DiffRevisions fetches two revisions of a document and returns the
+// changes from revision from to revision to, as computed by DiffDocuments
+// (requires API token).
+func (c *Client) DiffRevisions(collectionUUID, documentUUID string, from, to int) (Diff, error) {
+	a, err := c.GetRevision(collectionUUID, documentUUID, from)
+	if err != nil {
+		return nil, fmt.Errorf("get revision %d: %w", from, err)
+	}
+	b, err := c.GetRevision(collectionUUID, documentUUID, to)
+	if err != nil {
+		return nil, fmt.Errorf("get revision %d: %w", to, err)
+	}
+	return DiffDocuments(&a.Document, &b.Document), nil
+}
+
+// RestoreRevision makes the content of a previous revision the current
+// version of a document, recording it as a new revision, and returns the
+// restored document (requires API token).
+func (c *Client) RestoreRevision(collectionUUID, documentUUID string, number int) (*Document, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	url := fmt.Sprintf("%s/api/v1/revisions/%s/%s/%d/restore", c.BaseURL, collectionUUID, documentUUID, number)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var restored Document
+	if err := json.NewDecoder(resp.Body).Decode(&restored); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
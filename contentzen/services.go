@@ -0,0 +1,66 @@
+package contentzen
+
+//go:generate go run ../internal/mockgen -source services.go -out ../contentzentest/mocks.go -pkg contentzentest
+
+// DocumentService is the document part of the ContentZen API. The service
+// interfaces only cover the basic operations of each resource; helpers
+// built on them, such as publishing, revisions or Watch, are methods of
+// *Client.
+type DocumentService interface {
+	GetPublicDocuments(collectionUUID string) ([]Document, error)
+	GetPublicDocument(collectionUUID, documentUUID string) (*Document, error)
+	GetDocuments(collectionUUID string) ([]Document, error)
+	GetDocument(collectionUUID, documentUUID string) (*Document, error)
+	CreateDocument(collectionUUID string, doc *Document) (*Document, error)
+	UpdateDocument(collectionUUID, documentUUID string, doc *Document) (*Document, error)
+	DeleteDocument(collectionUUID, documentUUID string) error
+}
+
+// CollectionService is the collection and schema part of the ContentZen API.
+type CollectionService interface {
+	GetCollections() ([]Collection, error)
+	GetCollection(collectionUUID string) (*Collection, error)
+	CreateCollection(col *Collection) (*Collection, error)
+	UpdateCollection(collectionUUID string, col *Collection) (*Collection, error)
+	DeleteCollection(collectionUUID string) error
+	GetCollectionSchema(collectionUUID string) (map[string]interface{}, error)
+	GetCollectionFields(collectionUUID string) ([]CollectionField, error)
+	GetFieldTypes() ([]string, error)
+}
+
+// MediaService is the media library part of the ContentZen API.
+type MediaService interface {
+	ListMedia() ([]Media, error)
+	UploadMedia(filePath string) (*Media, error)
+	GetMedia(mediaUUID string) (*Media, error)
+	UpdateMedia(mediaUUID string, media *Media) (*Media, error)
+	DeleteMedia(mediaUUID string) error
+	DownloadMedia(mediaUUID, destPath string) error
+}
+
+// WebhookService is the webhook management part of the ContentZen API.
+type WebhookService interface {
+	ListWebhooks() ([]Webhook, error)
+	CreateWebhook(wh *Webhook) (*Webhook, error)
+	UpdateWebhook(webhookUUID string, wh *Webhook) (*Webhook, error)
+	DeleteWebhook(webhookUUID string) error
+}
+
+var (
+	_ DocumentService   = (*Client)(nil)
+	_ CollectionService = (*Client)(nil)
+	_ MediaService      = (*Client)(nil)
+	_ WebhookService    = (*Client)(nil)
+)
+
+// Documents returns the client as a DocumentService.
+func (c *Client) Documents() DocumentService { return c }
+
+// Collections returns the client as a CollectionService.
+func (c *Client) Collections() CollectionService { return c }
+
+// Media returns the client as a MediaService.
+func (c *Client) Media() MediaService { return c }
+
+// Webhooks returns the client as a WebhookService.
+func (c *Client) Webhooks() WebhookService { return c }
@@ -1,21 +1,50 @@
 package contentzen
 
+import "time"
+
 // Document represents a ContentZen document.
 type Document struct {
 	UUID    string                 `json:"uuid"`
 	Payload map[string]interface{} `json:"payload"`
 	Lang    string                 `json:"lang"`
-	State   string                 `json:"state"`
+	// State is one of the DocumentState values. It is a plain string, so
+	// that code assigning strings to it keeps compiling; compare it with
+	// DocumentState(doc.State).
+	State string `json:"state"`
+	// EntryUUID links the language variants of the same content. Documents
+	// created without one start a new entry.
+	EntryUUID string `json:"entry_uuid,omitempty"`
+	// Version is incremented by the server on every change and is used to
+	// detect conflicting updates.
+	Version int64 `json:"version,omitempty"`
+	// PublishAt and UnpublishAt are the times a scheduled publish or
+	// unpublish will happen, if any.
+	PublishAt   time.Time `json:"publish_at,omitzero"`
+	UnpublishAt time.Time `json:"unpublish_at,omitzero"`
 }
 
+// DocumentState is the publishing state of a document. A document
+// without a state is treated as a draft.
+type DocumentState string
+
+// Document states.
+const (
+	StateDraft     DocumentState = "draft"
+	StatePublished DocumentState = "published"
+	StateArchived  DocumentState = "archived"
+)
+
 // Collection represents a ContentZen collection.
 type Collection struct {
-	UUID        string                 `json:"uuid"`
-	Name        string                 `json:"name"`
-	DisplayName string                 `json:"display_name"`
-	Description string                 `json:"description"`
-	IsPublic    bool                   `json:"is_public"`
-	Fields      []CollectionField      `json:"fields"`
+	UUID        string            `json:"uuid"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Description string            `json:"description"`
+	IsPublic    bool              `json:"is_public"`
+	Fields      []CollectionField `json:"fields"`
+	// Version is incremented by the server on every change and is used to
+	// detect conflicting updates.
+	Version int64 `json:"version,omitempty"`
 }
 
 // CollectionField represents a field in a collection schema.
@@ -25,13 +54,39 @@ type CollectionField struct {
 	DisplayName string `json:"display_name"`
 	Required    bool   `json:"required"`
 	Unique      bool   `json:"unique,omitempty"`
+	// Collection is the UUID of the collection a reference field points to.
+	Collection string `json:"collection,omitempty"`
 }
 
+// Field types for CollectionField.Type.
+const (
+	FieldTypeText      = "text"
+	FieldTypeRichText  = "richtext"
+	FieldTypeMarkdown  = "markdown"
+	FieldTypeNumber    = "number"
+	FieldTypeBoolean   = "boolean"
+	FieldTypeDate      = "date"
+	FieldTypeJSON      = "json"
+	FieldTypeMedia     = "media"
+	FieldTypeReference = "reference"
+)
+
 // Media represents a media file in ContentZen.
 type Media struct {
-	UUID    string `json:"uuid"`
-	AltText string `json:"alt_text"`
-	URL     string `json:"url"`
+	UUID      string    `json:"uuid"`
+	AltText   string    `json:"alt_text"`
+	URL       string    `json:"url"`
+	Filename  string    `json:"filename,omitempty"`
+	MimeType  string    `json:"mime_type,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	Duration  float64   `json:"duration,omitempty"` // seconds, for audio and video
+	Checksum  string    `json:"checksum,omitempty"` // hex SHA-256 of the contents
+	Tags      []string  `json:"tags,omitempty"`
+	Folder    string    `json:"folder,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
 }
 
 // Webhook represents a webhook in ContentZen.
@@ -42,3 +97,18 @@ type Webhook struct {
 	Events []string `json:"events"`
 	Method string   `json:"method"`
 }
+
+// Webhook event types, for use in Webhook.Events.
+const (
+	EventDocumentCreated     = "document.created"
+	EventDocumentUpdated     = "document.updated"
+	EventDocumentDeleted     = "document.deleted"
+	EventDocumentPublished   = "document.published"
+	EventDocumentUnpublished = "document.unpublished"
+	EventCollectionCreated   = "collection.created"
+	EventCollectionUpdated   = "collection.updated"
+	EventCollectionDeleted   = "collection.deleted"
+	EventMediaCreated        = "media.created"
+	EventMediaUpdated        = "media.updated"
+	EventMediaDeleted        = "media.deleted"
+)
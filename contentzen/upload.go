@@ -0,0 +1,153 @@
+package contentzen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UploadOptions configures a media upload.
+type UploadOptions struct {
+	// AltText is stored as the alternative text of the uploaded media.
+	AltText string
+	// Tags and Folder organize the uploaded media in the library.
+	Tags   []string
+	Folder string
+	// Size is the number of bytes that will be read, used as the total in
+	// progress reports. Zero means unknown.
+	Size int64
+	// Progress, if set, receives progress reports as the file is sent.
+	Progress ProgressFunc
+	// Policy is checked before the upload starts; a violating file fails
+	// with an *UploadRejectedError. Defaults to Client.UploadPolicy.
+	Policy *UploadPolicy
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// UploadMediaFile uploads the file at filePath (requires API token). If
+// opts.Size is zero it is set from the file's size.
+func (c *Client) UploadMediaFile(ctx context.Context, filePath string, opts *UploadOptions) (*Media, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	o := UploadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Size == 0 {
+		if info, err := file.Stat(); err == nil {
+			o.Size = info.Size()
+		}
+	}
+	return c.UploadMediaReader(ctx, file, filepath.Base(filePath), "", &o)
+}
+
+// UploadMediaReader uploads the contents of r as a media file named
+// filename (requires API token). The multipart body is streamed to the
+// server as it is read, so memory use does not depend on the file size.
+// If contentType is empty, it is detected from the contents and filename.
+func (c *Client) UploadMediaReader(ctx context.Context, r io.Reader, filename, contentType string, opts *UploadOptions) (*Media, error) {
+	if c.APIToken == "" {
+		return nil, fmt.Errorf("API token required for this endpoint")
+	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	policy := opts.Policy
+	if policy == nil {
+		policy = c.UploadPolicy
+	}
+	r, contentType, err := inspectUpload(r, filename, contentType, opts.Size, policy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Progress != nil {
+		total := opts.Size
+		if total <= 0 {
+			total = -1
+		}
+		r = newProgressReader(r, total, opts.Progress)
+	}
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	done := make(chan struct{})
+	var writeErr error
+	go func() {
+		defer close(done)
+		writeErr = writeUploadBody(w, r, filename, contentType, opts)
+		pw.CloseWithError(writeErr)
+	}()
+	defer func() {
+		pr.Close()
+		<-done
+	}()
+
+	url := fmt.Sprintf("%s/api/v1/media/upload", c.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, pr)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		pr.Close()
+		<-done
+		var rejected *UploadRejectedError
+		if errors.As(writeErr, &rejected) {
+			return nil, rejected
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	var media Media
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// writeUploadBody writes the multipart upload body for r to w.
+func writeUploadBody(w *multipart.Writer, r io.Reader, filename, contentType string, opts *UploadOptions) error {
+	if opts.AltText != "" {
+		if err := w.WriteField("alt_text", opts.AltText); err != nil {
+			return err
+		}
+	}
+	if opts.Folder != "" {
+		if err := w.WriteField("folder", opts.Folder); err != nil {
+			return err
+		}
+	}
+	for _, tag := range opts.Tags {
+		if err := w.WriteField("tags", tag); err != nil {
+			return err
+		}
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(filename)))
+	h.Set("Content-Type", contentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return err
+	}
+	return w.Close()
+}
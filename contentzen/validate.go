@@ -0,0 +1,141 @@
+package contentzen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	// Register the decoders used to check image dimensions.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// sniffLen is how much of an upload is buffered to detect its content type
+// and image dimensions.
+const sniffLen = 64 << 10
+
+// ErrUploadRejected is matched by every *UploadRejectedError.
+var ErrUploadRejected = errors.New("upload rejected")
+
+// UploadPolicy restricts which files may be uploaded. Zero fields are not
+// enforced.
+type UploadPolicy struct {
+	// MaxSize is the maximum file size in bytes.
+	MaxSize int64
+	// AllowedTypes lists accepted MIME types; "image/*" accepts a whole class.
+	AllowedTypes []string
+	// MaxWidth and MaxHeight limit image dimensions in pixels. They are
+	// checked for GIF, JPEG and PNG images.
+	MaxWidth  int
+	MaxHeight int
+}
+
+// UploadRejectedError is returned when a file violates an UploadPolicy.
+type UploadRejectedError struct {
+	Filename    string
+	ContentType string
+	Reason      string
+}
+
+func (e *UploadRejectedError) Error() string {
+	return fmt.Sprintf("upload of %s rejected: %s", e.Filename, e.Reason)
+}
+
+func (e *UploadRejectedError) Is(target error) bool { return target == ErrUploadRejected }
+
+// DetectContentType returns the MIME type of data, the first bytes of a
+// file, falling back to the extension of filename when the content is not
+// recognized.
+func DetectContentType(filename string, data []byte) string {
+	ct := http.DetectContentType(data)
+	if ct == "application/octet-stream" || strings.HasPrefix(ct, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+			ct = byExt
+		}
+	}
+	return ct
+}
+
+// inspectUpload buffers the start of r to detect its content type (unless
+// contentType is given) and checks it against policy. It returns a reader
+// yielding the full contents and the content type to send. size is the
+// number of bytes r will yield, or zero if unknown; if the size is
+// unknown, the returned reader fails once MaxSize is exceeded.
+func inspectUpload(r io.Reader, filename, contentType string, size int64, policy *UploadPolicy) (io.Reader, string, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, "", err
+	}
+	head = head[:n]
+	if contentType == "" {
+		contentType = DetectContentType(filename, head)
+	}
+	full := io.MultiReader(bytes.NewReader(head), r)
+	if policy == nil {
+		return full, contentType, nil
+	}
+
+	reject := func(format string, args ...interface{}) error {
+		return &UploadRejectedError{Filename: filename, ContentType: contentType, Reason: fmt.Sprintf(format, args...)}
+	}
+	if policy.MaxSize > 0 {
+		if size > policy.MaxSize || (size <= 0 && int64(n) > policy.MaxSize) {
+			return nil, "", reject("size exceeds the maximum of %d bytes", policy.MaxSize)
+		}
+	}
+	if len(policy.AllowedTypes) > 0 && !mimeTypeAllowed(contentType, policy.AllowedTypes) {
+		return nil, "", reject("content type %s is not allowed", contentType)
+	}
+	if (policy.MaxWidth > 0 || policy.MaxHeight > 0) && strings.HasPrefix(contentType, "image/") {
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(head)); err == nil {
+			if policy.MaxWidth > 0 && cfg.Width > policy.MaxWidth || policy.MaxHeight > 0 && cfg.Height > policy.MaxHeight {
+				return nil, "", reject("image is %dx%d, larger than the maximum of %dx%d", cfg.Width, cfg.Height, policy.MaxWidth, policy.MaxHeight)
+			}
+		}
+	}
+	if policy.MaxSize > 0 && size <= 0 {
+		full = &maxSizeReader{r: full, remaining: policy.MaxSize, err: reject("size exceeds the maximum of %d bytes", policy.MaxSize)}
+	}
+	return full, contentType, nil
+}
+
+func mimeTypeAllowed(contentType string, allowed []string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = contentType
+	}
+	for _, a := range allowed {
+		if class, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(mt, class+"/") {
+				return true
+			}
+		} else if strings.EqualFold(a, mt) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSizeReader fails with err once more than remaining bytes are read.
+type maxSizeReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (m *maxSizeReader) Read(b []byte) (int, error) {
+	n, err := m.r.Read(b)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return n, m.err
+	}
+	return n, err
+}
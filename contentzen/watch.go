@@ -0,0 +1,235 @@
+package contentzen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// ChangeType is the kind of change reported by Watch.
+type ChangeType string
+
+// Change types.
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// ChangeEvent is a change to a document detected by Watch.
+type ChangeEvent struct {
+	Type           ChangeType
+	CollectionUUID string
+	// Before is the document as of the previous poll, nil for created
+	// documents. After is the document as of this poll, nil for deleted
+	// documents.
+	Before *Document
+	After  *Document
+	// Err is set, and the other fields are empty, when a poll failed. The
+	// watcher keeps polling with backoff.
+	Err error
+}
+
+// WatchOptions configures WatchWithOptions.
+type WatchOptions struct {
+	// Interval is the time between polls. Defaults to 30 seconds.
+	Interval time.Duration
+	// Jitter randomizes each delay by up to this fraction of it, and delays
+	// the first poll by up to Jitter times Interval, so that watchers
+	// started together do not poll in lockstep. Defaults to 0.1; a
+	// negative value disables jitter and values above 1 are treated as 1.
+	Jitter float64
+	// MaxBackoff caps the delay after consecutive failed polls, which
+	// doubles from Interval. Defaults to 5 minutes.
+	MaxBackoff time.Duration
+	// Checkpoint resumes a previous watch: changes since the checkpoint
+	// are reported by the first poll. Without one, the first poll only
+	// records the current documents, unless Initial is set.
+	Checkpoint *WatchCheckpoint
+	// Initial reports the documents found by the first poll as created
+	// when there is no checkpoint.
+	Initial bool
+	// OnCheckpoint, if set, is called with the watch state after the
+	// events of each poll have been received from the channel. Persisting
+	// it and passing it as Checkpoint resumes the watch without missing or
+	// repeating changes. Its Documents map is a copy that may be kept
+	// after the call.
+	OnCheckpoint func(*WatchCheckpoint)
+}
+
+// WatchCheckpoint is the state of a watch. It can be stored as JSON.
+type WatchCheckpoint struct {
+	// Time is when the poll the checkpoint was taken at completed.
+	Time time.Time `json:"time"`
+	// Documents maps document UUIDs to their state as of that poll.
+	Documents map[string]WatchedDocument `json:"documents"`
+}
+
+// WatchedDocument is a document recorded in a WatchCheckpoint.
+type WatchedDocument struct {
+	Hash     string   `json:"hash"`
+	Document Document `json:"document"`
+}
+
+// Watch polls the documents of a collection every interval and reports
+// their changes on the returned channel (requires API token). The channel
+// is closed when ctx is done. See WatchWithOptions.
+func (c *Client) Watch(ctx context.Context, collectionUUID string, interval time.Duration) <-chan ChangeEvent {
+	return c.WatchWithOptions(ctx, collectionUUID, &WatchOptions{Interval: interval})
+}
+
+// WatchWithOptions polls the documents of a collection with GetDocuments
+// and reports the documents created, updated or deleted since the
+// previous poll on the returned channel (requires API token). Documents
+// are compared by ContentHash, so only changes to their content are
+// reported, in order of UUID. Failed polls are reported as events with Err
+// set and retried with exponential backoff. The channel is closed when ctx
+// is done.
+func (c *Client) WatchWithOptions(ctx context.Context, collectionUUID string, opts *WatchOptions) <-chan ChangeEvent {
+	o := WatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = 30 * time.Second
+	}
+	if o.Jitter == 0 {
+		o.Jitter = 0.1
+	}
+	o.Jitter = min(o.Jitter, 1)
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+
+	out := make(chan ChangeEvent)
+	go func() {
+		defer close(out)
+		send := func(ev ChangeEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var known map[string]WatchedDocument
+		report := o.Checkpoint != nil || o.Initial
+		if o.Checkpoint != nil {
+			known = o.Checkpoint.Documents
+		}
+		delay := time.Duration(0)
+		if o.Jitter > 0 {
+			delay = time.Duration(rand.Float64() * o.Jitter * float64(o.Interval))
+		}
+		failures := 0
+		for {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			docs, err := c.getDocuments(ctx, collectionUUID)
+			if err != nil {
+				if ctx.Err() != nil || !send(ChangeEvent{CollectionUUID: collectionUUID, Err: err}) {
+					return
+				}
+				failures++
+				delay = jitter(backoffDelay(o.Interval, o.MaxBackoff, failures), o.Jitter)
+				continue
+			}
+			failures = 0
+			delay = jitter(o.Interval, o.Jitter)
+
+			current := make(map[string]WatchedDocument, len(docs))
+			for _, doc := range docs {
+				current[doc.UUID] = WatchedDocument{Hash: ContentHash(&doc), Document: doc}
+			}
+			if report {
+				for _, ev := range diffWatched(collectionUUID, known, current) {
+					if !send(ev) {
+						return
+					}
+				}
+			}
+			known, report = current, true
+			if o.OnCheckpoint != nil {
+				o.OnCheckpoint(&WatchCheckpoint{Time: time.Now(), Documents: maps.Clone(current)})
+			}
+		}
+	}()
+	return out
+}
+
+// diffWatched returns the changes from before to after, in order of
+// document UUID.
+func diffWatched(collectionUUID string, before, after map[string]WatchedDocument) []ChangeEvent {
+	ids := make([]string, 0, len(before)+len(after))
+	for id := range after {
+		ids = append(ids, id)
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var events []ChangeEvent
+	for _, id := range ids {
+		prev, existed := before[id]
+		cur, exists := after[id]
+		switch {
+		case !existed:
+			events = append(events, ChangeEvent{Type: ChangeCreated, CollectionUUID: collectionUUID, After: &cur.Document})
+		case !exists:
+			events = append(events, ChangeEvent{Type: ChangeDeleted, CollectionUUID: collectionUUID, Before: &prev.Document})
+		case prev.Hash != cur.Hash:
+			events = append(events, ChangeEvent{Type: ChangeUpdated, CollectionUUID: collectionUUID, Before: &prev.Document, After: &cur.Document})
+		}
+	}
+	return events
+}
+
+// ContentHash returns the hex SHA-256 digest of the content of a document:
+// its payload, language, state and schedule. Version is not included, so
+// saving a document unchanged does not change its hash.
+func ContentHash(doc *Document) string {
+	content := *doc
+	content.Version = 0
+	b, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// backoffDelay returns the delay after the given number of consecutive
+// failures: interval doubled per failure, capped at max.
+func backoffDelay(interval, max time.Duration, failures int) time.Duration {
+	d := interval
+	for i := 0; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// jitter randomizes d by up to the given fraction of it.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*fraction*float64(d))
+}
@@ -0,0 +1,229 @@
+package contentzen_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+	"github.com/contentzen-hub/sdk-go/contentzentest"
+)
+
+func newWatchTest(t *testing.T) (*contentzentest.Server, *contentzen.Client, string) {
+	t.Helper()
+	srv := contentzentest.NewServer()
+	t.Cleanup(srv.Close)
+	col := srv.AddCollection(contentzen.Collection{
+		Name:   "posts",
+		Fields: []contentzen.CollectionField{{Name: "title", Type: contentzen.FieldTypeText}},
+	})
+	return srv, srv.Client(), col.UUID
+}
+
+func nextEvent(t *testing.T, events <-chan contentzen.ChangeEvent) contentzen.ChangeEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("event channel closed")
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+	return contentzen.ChangeEvent{}
+}
+
+// checkpoints returns an OnCheckpoint callback and the channel it sends
+// the checkpoints to.
+func checkpoints() (func(*contentzen.WatchCheckpoint), <-chan *contentzen.WatchCheckpoint) {
+	ch := make(chan *contentzen.WatchCheckpoint, 100)
+	return func(cp *contentzen.WatchCheckpoint) { ch <- cp }, ch
+}
+
+func waitCheckpoint(t *testing.T, ch <-chan *contentzen.WatchCheckpoint) *contentzen.WatchCheckpoint {
+	t.Helper()
+	select {
+	case cp := <-ch:
+		return cp
+	case <-time.After(2 * time.Second):
+		t.Fatal("no checkpoint")
+	}
+	return nil
+}
+
+func TestWatchChanges(t *testing.T) {
+	srv, c, col := newWatchTest(t)
+	kept := srv.AddDocument(col, contentzen.Document{Payload: map[string]interface{}{"title": "kept"}})
+	edited := srv.AddDocument(col, contentzen.Document{Payload: map[string]interface{}{"title": "before"}})
+	deleted := srv.AddDocument(col, contentzen.Document{Payload: map[string]interface{}{"title": "deleted"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	onCheckpoint, cps := checkpoints()
+	events := c.WatchWithOptions(ctx, col, &contentzen.WatchOptions{Interval: 10 * time.Millisecond, Jitter: -1, OnCheckpoint: onCheckpoint})
+	waitCheckpoint(t, cps)
+
+	edited.Payload = map[string]interface{}{"title": "after"}
+	if _, err := c.UpdateDocument(col, edited.UUID, &edited); err != nil {
+		t.Fatal(err)
+	}
+	// Saving a document unchanged is not reported.
+	if _, err := c.UpdateDocument(col, kept.UUID, &kept); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteDocument(col, deleted.UUID); err != nil {
+		t.Fatal(err)
+	}
+	created, err := c.CreateDocument(col, &contentzen.Document{Payload: map[string]interface{}{"title": "created"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]contentzen.ChangeEvent)
+	for len(got) < 3 {
+		ev := nextEvent(t, events)
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+		if ev.CollectionUUID != col {
+			t.Errorf("event for collection %q", ev.CollectionUUID)
+		}
+		var id string
+		if ev.After != nil {
+			id = ev.After.UUID
+		} else if ev.Before != nil {
+			id = ev.Before.UUID
+		}
+		if _, dup := got[id]; dup {
+			t.Fatalf("second %s event for %s", ev.Type, id)
+		}
+		got[id] = ev
+	}
+
+	if ev := got[created.UUID]; ev.Type != contentzen.ChangeCreated || ev.Before != nil || ev.After.Payload["title"] != "created" {
+		t.Errorf("created event = %+v", ev)
+	}
+	if ev := got[edited.UUID]; ev.Type != contentzen.ChangeUpdated || ev.Before.Payload["title"] != "before" || ev.After.Payload["title"] != "after" {
+		t.Errorf("updated event = %+v", ev)
+	}
+	if ev := got[deleted.UUID]; ev.Type != contentzen.ChangeDeleted || ev.After != nil || ev.Before.Payload["title"] != "deleted" {
+		t.Errorf("deleted event = %+v", ev)
+	}
+	if _, ok := got[kept.UUID]; ok {
+		t.Errorf("event for unchanged document: %+v", got[kept.UUID])
+	}
+}
+
+func TestWatchCheckpointResume(t *testing.T) {
+	srv, c, col := newWatchTest(t)
+	doc := srv.AddDocument(col, contentzen.Document{Payload: map[string]interface{}{"title": "v1"}})
+	srv.AddDocument(col, contentzen.Document{Payload: map[string]interface{}{"title": "unchanged"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	onCheckpoint, cps := checkpoints()
+	events := c.WatchWithOptions(ctx, col, &contentzen.WatchOptions{Interval: 10 * time.Millisecond, Jitter: -1, OnCheckpoint: onCheckpoint})
+	cp := waitCheckpoint(t, cps)
+	cancel()
+	for range events {
+	}
+	if len(cp.Documents) != 2 {
+		t.Fatalf("checkpoint has %d documents, want 2", len(cp.Documents))
+	}
+
+	// Changes made while no watcher runs are reported by the first poll
+	// of the resumed watch.
+	doc.Payload = map[string]interface{}{"title": "v2"}
+	if _, err := c.UpdateDocument(col, doc.UUID, &doc); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	onCheckpoint, cps = checkpoints()
+	events = c.WatchWithOptions(ctx, col, &contentzen.WatchOptions{Interval: time.Hour, Jitter: -1, Checkpoint: cp, OnCheckpoint: onCheckpoint})
+	ev := nextEvent(t, events)
+	if ev.Type != contentzen.ChangeUpdated || ev.After.UUID != doc.UUID || ev.Before.Payload["title"] != "v1" || ev.After.Payload["title"] != "v2" {
+		t.Errorf("event = %+v", ev)
+	}
+	// The checkpoint is only taken once every event of the poll was
+	// received, so it also tells that no other event was sent.
+	waitCheckpoint(t, cps)
+}
+
+func TestWatchBackoff(t *testing.T) {
+	_, c, col := newWatchTest(t)
+	const failures = 3
+	var mu sync.Mutex
+	var polls []time.Time
+	transport := c.HTTPClient.Transport
+	c.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		polls = append(polls, time.Now())
+		n := len(polls)
+		mu.Unlock()
+		if n <= failures {
+			return nil, errors.New("unavailable")
+		}
+		return transport.RoundTrip(req)
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interval := 20 * time.Millisecond
+	onCheckpoint, cps := checkpoints()
+	events := c.WatchWithOptions(ctx, col, &contentzen.WatchOptions{Interval: interval, Jitter: -1, OnCheckpoint: onCheckpoint})
+	for i := 0; i < failures; i++ {
+		if ev := nextEvent(t, events); ev.Err == nil {
+			t.Fatalf("event %d = %+v, want an error", i, ev)
+		}
+	}
+	waitCheckpoint(t, cps)
+	waitCheckpoint(t, cps)
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The delay doubles after each failure and is back to the interval
+	// after a successful poll.
+	wants := []time.Duration{2 * interval, 4 * interval, 8 * interval, interval}
+	for i, want := range wants {
+		if got := polls[i+1].Sub(polls[i]); got < want {
+			t.Errorf("delay after poll %d = %v, want at least %v", i+1, got, want)
+		}
+	}
+	if got := polls[4].Sub(polls[3]); got >= 8*interval {
+		t.Errorf("delay after a successful poll = %v, want about %v", got, interval)
+	}
+}
+
+func TestWatchCancelDuringPoll(t *testing.T) {
+	_, c, col := newWatchTest(t)
+	started := make(chan struct{})
+	var once sync.Once
+	// The poll hangs until its request is cancelled.
+	c.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		once.Do(func() { close(started) })
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := c.WatchWithOptions(ctx, col, &contentzen.WatchOptions{Interval: 10 * time.Millisecond, Jitter: -1})
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no poll")
+	}
+	cancel()
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Fatalf("event after cancellation: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel not closed after cancellation")
+	}
+}
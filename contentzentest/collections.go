@@ -0,0 +1,166 @@
+package contentzentest
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// AddCollection stores col, assigning a UUID if it has none, and returns
+// the stored copy.
+func (s *Server) AddCollection(col contentzen.Collection) contentzen.Collection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if col.UUID == "" {
+		col.UUID = newUUID()
+	}
+	col.Version = 1
+	s.collections[col.UUID] = &collectionEntry{
+		seq:        s.nextSeq(),
+		collection: clone(col),
+		documents:  make(map[string]*documentEntry),
+	}
+	return clone(col)
+}
+
+func (s *Server) getCollections(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*collectionEntry, 0, len(s.collections))
+	for _, e := range s.collections {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	cols := make([]contentzen.Collection, 0, len(entries))
+	for _, e := range entries {
+		cols = append(cols, e.collection)
+	}
+	writeJSON(w, http.StatusOK, cols)
+}
+
+func (s *Server) getCollection(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e.collection)
+}
+
+func (s *Server) createCollection(w http.ResponseWriter, r *http.Request) {
+	var col contentzen.Collection
+	if !decodeJSON(w, r, &col) {
+		return
+	}
+	if col.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.collections {
+		if e.collection.Name == col.Name {
+			writeError(w, http.StatusConflict, "collection name already exists")
+			return
+		}
+	}
+	col.UUID = newUUID()
+	col.Version = 1
+	s.collections[col.UUID] = &collectionEntry{
+		seq:        s.nextSeq(),
+		collection: col,
+		documents:  make(map[string]*documentEntry),
+	}
+	writeJSON(w, http.StatusCreated, col)
+}
+
+func (s *Server) updateCollection(w http.ResponseWriter, r *http.Request) {
+	var col contentzen.Collection
+	if !decodeJSON(w, r, &col) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	if col.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	if !ifMatch(r, e.collection.Version) {
+		writeError(w, http.StatusPreconditionFailed, "collection was modified")
+		return
+	}
+	col.UUID = e.collection.UUID
+	col.Version = e.collection.Version + 1
+	e.collection = col
+	writeJSON(w, http.StatusOK, col)
+}
+
+func (s *Server) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uuid := r.PathValue("collection")
+	if _, ok := s.collections[uuid]; !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	delete(s.collections, uuid)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getCollectionSchema(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, f := range e.collection.Fields {
+		property := map[string]interface{}{
+			"type":         f.Type,
+			"display_name": f.DisplayName,
+			"unique":       f.Unique,
+		}
+		if f.Collection != "" {
+			property["collection"] = f.Collection
+		}
+		properties[f.Name] = property
+		if f.Required {
+			required = append(required, f.Name)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":       e.collection.Name,
+		"properties": properties,
+		"required":   required,
+	})
+}
+
+func (s *Server) getCollectionFields(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	fields := e.collection.Fields
+	if fields == nil {
+		fields = []contentzen.CollectionField{}
+	}
+	writeJSON(w, http.StatusOK, fields)
+}
+
+func (s *Server) getFieldTypes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, FieldTypes)
+}
@@ -0,0 +1,304 @@
+package contentzentest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// AddDocument stores doc in the collection, assigning a UUID if it has
+// none, and returns the stored copy. It panics if the collection does not
+// exist.
+func (s *Server) AddDocument(collectionUUID string, doc contentzen.Document) contentzen.Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[collectionUUID]
+	if !ok {
+		panic(fmt.Sprintf("contentzentest: collection %q does not exist", collectionUUID))
+	}
+	if doc.UUID == "" {
+		doc.UUID = newUUID()
+	}
+	if doc.EntryUUID == "" {
+		doc.EntryUUID = doc.UUID
+	}
+	e := &documentEntry{seq: s.nextSeq()}
+	doc = e.save(clone(doc), "")
+	col.documents[doc.UUID] = e
+	return clone(doc)
+}
+
+// Documents returns a snapshot of the documents stored in a collection, in
+// creation order.
+func (s *Server) Documents(collectionUUID string) []contentzen.Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[collectionUUID]
+	if !ok {
+		return nil
+	}
+	return clone(col.sortedDocuments(func(*documentEntry) bool { return true }))
+}
+
+func (c *collectionEntry) sortedDocuments(keep func(*documentEntry) bool) []contentzen.Document {
+	entries := make([]*documentEntry, 0, len(c.documents))
+	for _, e := range c.documents {
+		if keep(e) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	docs := make([]contentzen.Document, 0, len(entries))
+	for _, e := range entries {
+		docs = append(docs, e.document)
+	}
+	return docs
+}
+
+// validate checks doc against the collection's required and unique fields.
+func (c *collectionEntry) validate(doc *contentzen.Document) error {
+	for _, f := range c.collection.Fields {
+		v, ok := doc.Payload[f.Name]
+		if f.Required && (!ok || v == nil || v == "") {
+			return fmt.Errorf("field %q is required", f.Name)
+		}
+		if f.Unique && ok {
+			for _, e := range c.documents {
+				if e.document.UUID != doc.UUID && jsonEqual(e.document.Payload[f.Name], v) {
+					return fmt.Errorf("field %q must be unique", f.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) getPublicDocuments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok || !col.collection.IsPublic {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	state := r.URL.Query().Get("state")
+	docs := col.sortedDocuments(func(e *documentEntry) bool {
+		return state == "" || string(e.document.State) == state
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  docs,
+		"total": len(docs),
+	})
+}
+
+func (s *Server) getPublicDocument(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok || !col.collection.IsPublic {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	e, ok := col.documents[r.PathValue("document")]
+	if !ok || contentzen.DocumentState(e.document.State) != contentzen.StatePublished {
+		writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e.document)
+}
+
+func (s *Server) getDocuments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, col.sortedDocuments(func(*documentEntry) bool { return true }))
+}
+
+func (s *Server) getDocument(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, e := s.lookupDocument(w, r)
+	if e == nil {
+		return
+	}
+	w.Header().Set("ETag", contentzen.ETag(e.document.Version))
+	writeJSON(w, http.StatusOK, e.document)
+}
+
+func (s *Server) createDocument(w http.ResponseWriter, r *http.Request) {
+	var doc contentzen.Document
+	if !decodeJSON(w, r, &doc) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	doc.UUID = newUUID()
+	if doc.EntryUUID == "" {
+		doc.EntryUUID = doc.UUID
+	}
+	if doc.State == "" {
+		doc.State = string(contentzen.StateDraft)
+	}
+	if err := col.validate(&doc); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	e := &documentEntry{seq: s.nextSeq()}
+	doc = e.save(doc, revisionAuthor)
+	col.documents[doc.UUID] = e
+	writeJSON(w, http.StatusCreated, doc)
+}
+
+func (s *Server) updateDocument(w http.ResponseWriter, r *http.Request) {
+	var doc contentzen.Document
+	if !decodeJSON(w, r, &doc) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	e, ok := col.documents[r.PathValue("document")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	if !ifMatch(r, e.document.Version) {
+		writeError(w, http.StatusPreconditionFailed, "document was modified")
+		return
+	}
+	doc.UUID = e.document.UUID
+	if doc.EntryUUID == "" {
+		doc.EntryUUID = e.document.EntryUUID
+	}
+	if doc.State == "" {
+		doc.State = e.document.State
+	}
+	if err := col.validate(&doc); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	doc = e.save(doc, revisionAuthor)
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// patchDocument applies a JSON Merge Patch or JSON Patch, depending on the
+// request's Content-Type. A failed JSON Patch test answers 409.
+func (s *Server) patchDocument(w http.ResponseWriter, r *http.Request) {
+	var patch contentzen.Patch
+	switch mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType {
+	case contentzen.MergePatch{}.ContentType():
+		var p contentzen.MergePatch
+		if !decodeJSON(w, r, &p) {
+			return
+		}
+		patch = p
+	case contentzen.JSONPatch{}.ContentType():
+		var p contentzen.JSONPatch
+		if !decodeJSON(w, r, &p) {
+			return
+		}
+		patch = p
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "unsupported patch type "+mediaType)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, e := s.lookupDocument(w, r)
+	if e == nil {
+		return
+	}
+	if !ifMatch(r, e.document.Version) {
+		writeError(w, http.StatusPreconditionFailed, "document was modified")
+		return
+	}
+	current := clone(e.document)
+	patched, err := patch.Apply(&current)
+	if errors.Is(err, contentzen.ErrPatchTestFailed) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	doc := *patched
+	doc.UUID = e.document.UUID
+	doc.EntryUUID = e.document.EntryUUID
+	if err := col.validate(&doc); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	doc = e.save(doc, revisionAuthor)
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (s *Server) deleteDocument(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	uuid := r.PathValue("document")
+	if _, ok := col.documents[uuid]; !ok {
+		writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	delete(col.documents, uuid)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupDocument resolves the document named in the request path, writing
+// a 404 if it does not exist. The caller must hold s.mu.
+func (s *Server) lookupDocument(w http.ResponseWriter, r *http.Request) (*collectionEntry, *documentEntry) {
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return nil, nil
+	}
+	e, ok := col.documents[r.PathValue("document")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "document not found")
+		return nil, nil
+	}
+	return col, e
+}
+
+// clone returns a deep copy of v by round-tripping it through JSON.
+func clone[T any](v T) T {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var out T
+	if err := json.Unmarshal(b, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
@@ -0,0 +1,103 @@
+package contentzentest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// variants returns the documents sharing e's content entry, in creation
+// order.
+func (c *collectionEntry) variants(e *documentEntry, keep func(*documentEntry) bool) []contentzen.Document {
+	entry := e.document.EntryUUID
+	return c.sortedDocuments(func(v *documentEntry) bool {
+		return v.document.EntryUUID == entry && keep(v)
+	})
+}
+
+func (s *Server) listLocales(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, e := s.lookupDocument(w, r)
+	if e == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, col.variants(e, func(*documentEntry) bool { return true }))
+}
+
+func (s *Server) listPublicLocales(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[r.PathValue("collection")]
+	if !ok || !col.collection.IsPublic {
+		writeError(w, http.StatusNotFound, "collection not found")
+		return
+	}
+	// The requested variant itself may be unpublished; the entry is only
+	// hidden if none of its variants is published.
+	e, ok := col.documents[r.PathValue("document")]
+	var docs []contentzen.Document
+	if ok {
+		docs = col.variants(e, func(v *documentEntry) bool {
+			return contentzen.DocumentState(v.document.State) == contentzen.StatePublished
+		})
+	}
+	if len(docs) == 0 {
+		writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  docs,
+		"total": len(docs),
+	})
+}
+
+// putLocale creates or replaces the variant of a document's content entry
+// in the language named in the path.
+func (s *Server) putLocale(w http.ResponseWriter, r *http.Request) {
+	var doc contentzen.Document
+	if !decodeJSON(w, r, &doc) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, e := s.lookupDocument(w, r)
+	if e == nil {
+		return
+	}
+	doc.Lang = r.PathValue("lang")
+	doc.EntryUUID = e.document.EntryUUID
+
+	var existing *documentEntry
+	for _, v := range col.documents {
+		if v.document.EntryUUID == doc.EntryUUID && strings.EqualFold(v.document.Lang, doc.Lang) {
+			existing = v
+			break
+		}
+	}
+	if existing != nil {
+		doc.UUID = existing.document.UUID
+		if doc.State == "" {
+			doc.State = existing.document.State
+		}
+	} else {
+		doc.UUID = newUUID()
+		if doc.State == "" {
+			doc.State = string(contentzen.StateDraft)
+		}
+	}
+	if err := col.validate(&doc); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if existing != nil {
+		doc = existing.save(doc, revisionAuthor)
+		writeJSON(w, http.StatusOK, doc)
+		return
+	}
+	v := &documentEntry{seq: s.nextSeq()}
+	doc = v.save(doc, revisionAuthor)
+	col.documents[doc.UUID] = v
+	writeJSON(w, http.StatusCreated, doc)
+}
@@ -0,0 +1,251 @@
+package contentzentest
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// AddMedia stores a media file with the given filename and contents and
+// returns its metadata.
+func (s *Server) AddMedia(filename string, data []byte) contentzen.Media {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storeMedia(contentzen.Media{}, filename, data)
+}
+
+// MediaData returns the stored contents of a media file.
+func (s *Server) MediaData(mediaUUID string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.media[mediaUUID]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), e.data...), true
+}
+
+// storeMedia adds a media entry with the user-provided fields of meta and
+// the metadata derived from data. The MIME type is detected unless meta
+// declares a specific one. The caller must hold s.mu.
+func (s *Server) storeMedia(meta contentzen.Media, filename string, data []byte) contentzen.Media {
+	filename = path.Base(filename)
+	uuid := newUUID()
+	now := time.Now().UTC()
+	m := contentzen.Media{
+		UUID:      uuid,
+		AltText:   meta.AltText,
+		URL:       s.URL + "/files/" + uuid + "/" + url.PathEscape(filename),
+		Filename:  filename,
+		MimeType:  meta.MimeType,
+		Size:      int64(len(data)),
+		Checksum:  etag(data),
+		Tags:      meta.Tags,
+		Folder:    meta.Folder,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if m.MimeType == "" || m.MimeType == "application/octet-stream" {
+		m.MimeType = detectMimeType(filename, data)
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		m.Width, m.Height = cfg.Width, cfg.Height
+	}
+	s.media[uuid] = &mediaEntry{seq: s.nextSeq(), media: m, filename: filename, data: data}
+	return m
+}
+
+func detectMimeType(filename string, data []byte) string {
+	mt, _, _ := strings.Cut(contentzen.DetectContentType(filename, data), ";")
+	return mt
+}
+
+// sortedMedia returns the media entries in creation order. The caller must
+// hold s.mu.
+func (s *Server) sortedMedia() []contentzen.Media {
+	entries := make([]*mediaEntry, 0, len(s.media))
+	for _, e := range s.media {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	media := make([]contentzen.Media, 0, len(entries))
+	for _, e := range entries {
+		media = append(media, e.media)
+	}
+	return media
+}
+
+func (s *Server) listMedia(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.sortedMedia())
+}
+
+func (s *Server) listMediaPage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var after, before time.Time
+	for key, t := range map[string]*time.Time{"created_after": &after, "created_before": &before} {
+		if v := q.Get(key); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid "+key)
+				return
+			}
+			*t = parsed
+		}
+	}
+	page, perPage := 1, 20
+	for key, n := range map[string]*int{"page": &page, "per_page": &perPage} {
+		if v := q.Get(key); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 {
+				writeError(w, http.StatusBadRequest, "invalid "+key)
+				return
+			}
+			*n = parsed
+		}
+	}
+	mimeType, tag, folder, name := q.Get("mime_type"), q.Get("tag"), q.Get("folder"), strings.ToLower(q.Get("name"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := []contentzen.Media{}
+	for _, m := range s.sortedMedia() {
+		switch {
+		case mimeType != "" && !matchMimeType(mimeType, m.MimeType),
+			tag != "" && !slices.Contains(m.Tags, tag),
+			folder != "" && m.Folder != folder,
+			name != "" && !strings.Contains(strings.ToLower(m.Filename), name),
+			!after.IsZero() && !m.CreatedAt.After(after),
+			!before.IsZero() && !m.CreatedAt.Before(before):
+			continue
+		}
+		matched = append(matched, m)
+	}
+	start := min((page-1)*perPage, len(matched))
+	end := min(start+perPage, len(matched))
+	writeJSON(w, http.StatusOK, contentzen.MediaPage{
+		Media:   matched[start:end],
+		Page:    page,
+		PerPage: perPage,
+		Total:   len(matched),
+	})
+}
+
+func matchMimeType(pattern, mimeType string) bool {
+	if class, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mimeType, class+"/")
+	}
+	return pattern == mimeType
+}
+
+func (s *Server) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "missing file part: "+err.Error())
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	meta := contentzen.Media{
+		AltText:  r.FormValue("alt_text"),
+		Tags:     r.MultipartForm.Value["tags"],
+		Folder:   r.FormValue("folder"),
+		MimeType: header.Header.Get("Content-Type"),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.storeMedia(meta, header.Filename, data)
+	writeJSON(w, http.StatusCreated, m)
+}
+
+func (s *Server) getMedia(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.media[r.PathValue("media")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "media not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e.media)
+}
+
+func (s *Server) updateMedia(w http.ResponseWriter, r *http.Request) {
+	var m contentzen.Media
+	if !decodeJSON(w, r, &m) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.media[r.PathValue("media")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "media not found")
+		return
+	}
+	e.media.AltText = m.AltText
+	e.media.Tags = m.Tags
+	e.media.Folder = m.Folder
+	e.media.UpdatedAt = time.Now().UTC()
+	writeJSON(w, http.StatusOK, e.media)
+}
+
+func (s *Server) deleteMedia(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uuid := r.PathValue("media")
+	if _, ok := s.media[uuid]; !ok {
+		writeError(w, http.StatusNotFound, "media not found")
+		return
+	}
+	delete(s.media, uuid)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) downloadMedia(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	e, ok := s.media[r.PathValue("media")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "media not found")
+		return
+	}
+	s.writeMediaData(w, r, e)
+}
+
+// serveFile serves media contents publicly from the URL reported in Media.URL.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	e, ok := s.media[r.PathValue("media")]
+	s.mu.Unlock()
+	if !ok || e.filename != r.PathValue("filename") {
+		http.NotFound(w, r)
+		return
+	}
+	s.writeMediaData(w, r, e)
+}
+
+// writeMediaData serves the contents of e, honouring Range requests and
+// reporting the SHA-256 digest in contentzen.ChecksumHeader.
+func (s *Server) writeMediaData(w http.ResponseWriter, r *http.Request, e *mediaEntry) {
+	w.Header().Set("Content-Type", http.DetectContentType(e.data))
+	w.Header().Set(contentzen.ChecksumHeader, etag(e.data))
+	w.Header().Set("ETag", `"`+etag(e.data)+`"`)
+	http.ServeContent(w, r, e.filename, time.Time{}, bytes.NewReader(e.data))
+}
@@ -0,0 +1,49 @@
+package contentzentest
+
+import "sync"
+
+// Call is a method call recorded by a mock.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// callRecorder records the calls made on a mock. It is embedded in the
+// generated mocks in mocks.go.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *callRecorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns all recorded calls in order.
+func (r *callRecorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls to method in order.
+func (r *callRecorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Reset forgets all recorded calls.
+func (r *callRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
@@ -0,0 +1,289 @@
+// Code generated by internal/mockgen from contentzen/services.go. DO NOT EDIT.
+
+package contentzentest
+
+import (
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// MockDocumentService is a mock contentzen.DocumentService that records calls.
+type MockDocumentService struct {
+	callRecorder
+
+	GetPublicDocumentsFunc func(collectionUUID string) ([]contentzen.Document, error)
+	GetPublicDocumentFunc  func(collectionUUID, documentUUID string) (*contentzen.Document, error)
+	GetDocumentsFunc       func(collectionUUID string) ([]contentzen.Document, error)
+	GetDocumentFunc        func(collectionUUID, documentUUID string) (*contentzen.Document, error)
+	CreateDocumentFunc     func(collectionUUID string, doc *contentzen.Document) (*contentzen.Document, error)
+	UpdateDocumentFunc     func(collectionUUID, documentUUID string, doc *contentzen.Document) (*contentzen.Document, error)
+	DeleteDocumentFunc     func(collectionUUID, documentUUID string) error
+}
+
+var _ contentzen.DocumentService = (*MockDocumentService)(nil)
+
+// GetPublicDocuments records the call and invokes GetPublicDocumentsFunc if set.
+func (m *MockDocumentService) GetPublicDocuments(collectionUUID string) (r0 []contentzen.Document, r1 error) {
+	m.record("GetPublicDocuments", collectionUUID)
+	if m.GetPublicDocumentsFunc != nil {
+		return m.GetPublicDocumentsFunc(collectionUUID)
+	}
+	return
+}
+
+// GetPublicDocument records the call and invokes GetPublicDocumentFunc if set.
+func (m *MockDocumentService) GetPublicDocument(collectionUUID string, documentUUID string) (r0 *contentzen.Document, r1 error) {
+	m.record("GetPublicDocument", collectionUUID, documentUUID)
+	if m.GetPublicDocumentFunc != nil {
+		return m.GetPublicDocumentFunc(collectionUUID, documentUUID)
+	}
+	return
+}
+
+// GetDocuments records the call and invokes GetDocumentsFunc if set.
+func (m *MockDocumentService) GetDocuments(collectionUUID string) (r0 []contentzen.Document, r1 error) {
+	m.record("GetDocuments", collectionUUID)
+	if m.GetDocumentsFunc != nil {
+		return m.GetDocumentsFunc(collectionUUID)
+	}
+	return
+}
+
+// GetDocument records the call and invokes GetDocumentFunc if set.
+func (m *MockDocumentService) GetDocument(collectionUUID string, documentUUID string) (r0 *contentzen.Document, r1 error) {
+	m.record("GetDocument", collectionUUID, documentUUID)
+	if m.GetDocumentFunc != nil {
+		return m.GetDocumentFunc(collectionUUID, documentUUID)
+	}
+	return
+}
+
+// CreateDocument records the call and invokes CreateDocumentFunc if set.
+func (m *MockDocumentService) CreateDocument(collectionUUID string, doc *contentzen.Document) (r0 *contentzen.Document, r1 error) {
+	m.record("CreateDocument", collectionUUID, doc)
+	if m.CreateDocumentFunc != nil {
+		return m.CreateDocumentFunc(collectionUUID, doc)
+	}
+	return
+}
+
+// UpdateDocument records the call and invokes UpdateDocumentFunc if set.
+func (m *MockDocumentService) UpdateDocument(collectionUUID string, documentUUID string, doc *contentzen.Document) (r0 *contentzen.Document, r1 error) {
+	m.record("UpdateDocument", collectionUUID, documentUUID, doc)
+	if m.UpdateDocumentFunc != nil {
+		return m.UpdateDocumentFunc(collectionUUID, documentUUID, doc)
+	}
+	return
+}
+
+// DeleteDocument records the call and invokes DeleteDocumentFunc if set.
+func (m *MockDocumentService) DeleteDocument(collectionUUID string, documentUUID string) (r0 error) {
+	m.record("DeleteDocument", collectionUUID, documentUUID)
+	if m.DeleteDocumentFunc != nil {
+		return m.DeleteDocumentFunc(collectionUUID, documentUUID)
+	}
+	return
+}
+
+// MockCollectionService is a mock contentzen.CollectionService that records calls.
+type MockCollectionService struct {
+	callRecorder
+
+	GetCollectionsFunc      func() ([]contentzen.Collection, error)
+	GetCollectionFunc       func(collectionUUID string) (*contentzen.Collection, error)
+	CreateCollectionFunc    func(col *contentzen.Collection) (*contentzen.Collection, error)
+	UpdateCollectionFunc    func(collectionUUID string, col *contentzen.Collection) (*contentzen.Collection, error)
+	DeleteCollectionFunc    func(collectionUUID string) error
+	GetCollectionSchemaFunc func(collectionUUID string) (map[string]interface{}, error)
+	GetCollectionFieldsFunc func(collectionUUID string) ([]contentzen.CollectionField, error)
+	GetFieldTypesFunc       func() ([]string, error)
+}
+
+var _ contentzen.CollectionService = (*MockCollectionService)(nil)
+
+// GetCollections records the call and invokes GetCollectionsFunc if set.
+func (m *MockCollectionService) GetCollections() (r0 []contentzen.Collection, r1 error) {
+	m.record("GetCollections")
+	if m.GetCollectionsFunc != nil {
+		return m.GetCollectionsFunc()
+	}
+	return
+}
+
+// GetCollection records the call and invokes GetCollectionFunc if set.
+func (m *MockCollectionService) GetCollection(collectionUUID string) (r0 *contentzen.Collection, r1 error) {
+	m.record("GetCollection", collectionUUID)
+	if m.GetCollectionFunc != nil {
+		return m.GetCollectionFunc(collectionUUID)
+	}
+	return
+}
+
+// CreateCollection records the call and invokes CreateCollectionFunc if set.
+func (m *MockCollectionService) CreateCollection(col *contentzen.Collection) (r0 *contentzen.Collection, r1 error) {
+	m.record("CreateCollection", col)
+	if m.CreateCollectionFunc != nil {
+		return m.CreateCollectionFunc(col)
+	}
+	return
+}
+
+// UpdateCollection records the call and invokes UpdateCollectionFunc if set.
+func (m *MockCollectionService) UpdateCollection(collectionUUID string, col *contentzen.Collection) (r0 *contentzen.Collection, r1 error) {
+	m.record("UpdateCollection", collectionUUID, col)
+	if m.UpdateCollectionFunc != nil {
+		return m.UpdateCollectionFunc(collectionUUID, col)
+	}
+	return
+}
+
+// DeleteCollection records the call and invokes DeleteCollectionFunc if set.
+func (m *MockCollectionService) DeleteCollection(collectionUUID string) (r0 error) {
+	m.record("DeleteCollection", collectionUUID)
+	if m.DeleteCollectionFunc != nil {
+		return m.DeleteCollectionFunc(collectionUUID)
+	}
+	return
+}
+
+// GetCollectionSchema records the call and invokes GetCollectionSchemaFunc if set.
+func (m *MockCollectionService) GetCollectionSchema(collectionUUID string) (r0 map[string]interface{}, r1 error) {
+	m.record("GetCollectionSchema", collectionUUID)
+	if m.GetCollectionSchemaFunc != nil {
+		return m.GetCollectionSchemaFunc(collectionUUID)
+	}
+	return
+}
+
+// GetCollectionFields records the call and invokes GetCollectionFieldsFunc if set.
+func (m *MockCollectionService) GetCollectionFields(collectionUUID string) (r0 []contentzen.CollectionField, r1 error) {
+	m.record("GetCollectionFields", collectionUUID)
+	if m.GetCollectionFieldsFunc != nil {
+		return m.GetCollectionFieldsFunc(collectionUUID)
+	}
+	return
+}
+
+// GetFieldTypes records the call and invokes GetFieldTypesFunc if set.
+func (m *MockCollectionService) GetFieldTypes() (r0 []string, r1 error) {
+	m.record("GetFieldTypes")
+	if m.GetFieldTypesFunc != nil {
+		return m.GetFieldTypesFunc()
+	}
+	return
+}
+
+// MockMediaService is a mock contentzen.MediaService that records calls.
+type MockMediaService struct {
+	callRecorder
+
+	ListMediaFunc     func() ([]contentzen.Media, error)
+	UploadMediaFunc   func(filePath string) (*contentzen.Media, error)
+	GetMediaFunc      func(mediaUUID string) (*contentzen.Media, error)
+	UpdateMediaFunc   func(mediaUUID string, media *contentzen.Media) (*contentzen.Media, error)
+	DeleteMediaFunc   func(mediaUUID string) error
+	DownloadMediaFunc func(mediaUUID, destPath string) error
+}
+
+var _ contentzen.MediaService = (*MockMediaService)(nil)
+
+// ListMedia records the call and invokes ListMediaFunc if set.
+func (m *MockMediaService) ListMedia() (r0 []contentzen.Media, r1 error) {
+	m.record("ListMedia")
+	if m.ListMediaFunc != nil {
+		return m.ListMediaFunc()
+	}
+	return
+}
+
+// UploadMedia records the call and invokes UploadMediaFunc if set.
+func (m *MockMediaService) UploadMedia(filePath string) (r0 *contentzen.Media, r1 error) {
+	m.record("UploadMedia", filePath)
+	if m.UploadMediaFunc != nil {
+		return m.UploadMediaFunc(filePath)
+	}
+	return
+}
+
+// GetMedia records the call and invokes GetMediaFunc if set.
+func (m *MockMediaService) GetMedia(mediaUUID string) (r0 *contentzen.Media, r1 error) {
+	m.record("GetMedia", mediaUUID)
+	if m.GetMediaFunc != nil {
+		return m.GetMediaFunc(mediaUUID)
+	}
+	return
+}
+
+// UpdateMedia records the call and invokes UpdateMediaFunc if set.
+func (m *MockMediaService) UpdateMedia(mediaUUID string, media *contentzen.Media) (r0 *contentzen.Media, r1 error) {
+	m.record("UpdateMedia", mediaUUID, media)
+	if m.UpdateMediaFunc != nil {
+		return m.UpdateMediaFunc(mediaUUID, media)
+	}
+	return
+}
+
+// DeleteMedia records the call and invokes DeleteMediaFunc if set.
+func (m *MockMediaService) DeleteMedia(mediaUUID string) (r0 error) {
+	m.record("DeleteMedia", mediaUUID)
+	if m.DeleteMediaFunc != nil {
+		return m.DeleteMediaFunc(mediaUUID)
+	}
+	return
+}
+
+// DownloadMedia records the call and invokes DownloadMediaFunc if set.
+func (m *MockMediaService) DownloadMedia(mediaUUID string, destPath string) (r0 error) {
+	m.record("DownloadMedia", mediaUUID, destPath)
+	if m.DownloadMediaFunc != nil {
+		return m.DownloadMediaFunc(mediaUUID, destPath)
+	}
+	return
+}
+
+// MockWebhookService is a mock contentzen.WebhookService that records calls.
+type MockWebhookService struct {
+	callRecorder
+
+	ListWebhooksFunc  func() ([]contentzen.Webhook, error)
+	CreateWebhookFunc func(wh *contentzen.Webhook) (*contentzen.Webhook, error)
+	UpdateWebhookFunc func(webhookUUID string, wh *contentzen.Webhook) (*contentzen.Webhook, error)
+	DeleteWebhookFunc func(webhookUUID string) error
+}
+
+var _ contentzen.WebhookService = (*MockWebhookService)(nil)
+
+// ListWebhooks records the call and invokes ListWebhooksFunc if set.
+func (m *MockWebhookService) ListWebhooks() (r0 []contentzen.Webhook, r1 error) {
+	m.record("ListWebhooks")
+	if m.ListWebhooksFunc != nil {
+		return m.ListWebhooksFunc()
+	}
+	return
+}
+
+// CreateWebhook records the call and invokes CreateWebhookFunc if set.
+func (m *MockWebhookService) CreateWebhook(wh *contentzen.Webhook) (r0 *contentzen.Webhook, r1 error) {
+	m.record("CreateWebhook", wh)
+	if m.CreateWebhookFunc != nil {
+		return m.CreateWebhookFunc(wh)
+	}
+	return
+}
+
+// UpdateWebhook records the call and invokes UpdateWebhookFunc if set.
+func (m *MockWebhookService) UpdateWebhook(webhookUUID string, wh *contentzen.Webhook) (r0 *contentzen.Webhook, r1 error) {
+	m.record("UpdateWebhook", webhookUUID, wh)
+	if m.UpdateWebhookFunc != nil {
+		return m.UpdateWebhookFunc(webhookUUID, wh)
+	}
+	return
+}
+
+// DeleteWebhook records the call and invokes DeleteWebhookFunc if set.
+func (m *MockWebhookService) DeleteWebhook(webhookUUID string) (r0 error) {
+	m.record("DeleteWebhook", webhookUUID)
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(webhookUUID)
+	}
+	return
+}
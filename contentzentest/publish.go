@@ -0,0 +1,122 @@
+package contentzentest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// now returns the server's current time. The caller must hold s.mu.
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// applySchedules publishes and unpublishes the documents whose scheduled
+// time has passed before every request is served.
+func (s *Server) applySchedules(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		now := s.now()
+		for _, col := range s.collections {
+			for _, e := range col.documents {
+				e.applySchedule(now)
+			}
+		}
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (e *documentEntry) applySchedule(now time.Time) {
+	doc := e.document
+	changed := false
+	if !doc.PublishAt.IsZero() && !doc.PublishAt.After(now) {
+		if contentzen.CanTransition(contentzen.DocumentState(doc.State), contentzen.StatePublished) {
+			doc.State = string(contentzen.StatePublished)
+		}
+		doc.PublishAt = time.Time{}
+		changed = true
+	}
+	if !doc.UnpublishAt.IsZero() && !doc.UnpublishAt.After(now) {
+		if contentzen.DocumentState(doc.State) == contentzen.StatePublished {
+			doc.State = string(contentzen.StateDraft)
+		}
+		doc.UnpublishAt = time.Time{}
+		changed = true
+	}
+	if changed {
+		e.save(doc, "scheduler")
+	}
+}
+
+// transitionDocument returns a handler moving a document to state to,
+// answering 409 if the transition is not allowed or, if from is set, the
+// document is in another state. A document already in state to is
+// returned unchanged.
+func (s *Server) transitionDocument(from, to contentzen.DocumentState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, e := s.lookupDocument(w, r)
+		if e == nil {
+			return
+		}
+		state := contentzen.DocumentState(e.document.State)
+		if state == "" {
+			state = contentzen.StateDraft
+		}
+		if state == to {
+			writeJSON(w, http.StatusOK, e.document)
+			return
+		}
+		if from != "" && state != from || !contentzen.CanTransition(state, to) {
+			writeError(w, http.StatusConflict, (&contentzen.TransitionError{From: contentzen.DocumentState(e.document.State), To: to}).Error())
+			return
+		}
+		doc := clone(e.document)
+		doc.State = string(to)
+		if to != contentzen.StateDraft {
+			doc.PublishAt = time.Time{}
+		}
+		if to != contentzen.StatePublished {
+			doc.UnpublishAt = time.Time{}
+		}
+		doc = e.save(doc, revisionAuthor)
+		writeJSON(w, http.StatusOK, doc)
+	}
+}
+
+func (s *Server) scheduleDocument(w http.ResponseWriter, r *http.Request) {
+	var body map[string]*time.Time
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, e := s.lookupDocument(w, r)
+	if e == nil {
+		return
+	}
+	doc := clone(e.document)
+	for field, at := range body {
+		var t time.Time
+		if at != nil {
+			t = *at
+		}
+		switch field {
+		case "publish_at":
+			doc.PublishAt = t
+		case "unpublish_at":
+			doc.UnpublishAt = t
+		default:
+			writeError(w, http.StatusBadRequest, "unknown schedule field "+field)
+			return
+		}
+	}
+	doc = e.save(doc, revisionAuthor)
+	writeJSON(w, http.StatusOK, doc)
+}
@@ -0,0 +1,370 @@
+package contentzentest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	// ModeAuto replays the cassette if it exists and records it otherwise.
+	ModeAuto Mode = iota
+	// ModeRecord forwards requests to the real transport and records them.
+	ModeRecord
+	// ModeReplay serves responses from the cassette without network access.
+	ModeReplay
+)
+
+// Redacted replaces scrubbed values in cassettes.
+const Redacted = "[REDACTED]"
+
+// scrubbedHeaders are never written to cassettes.
+var scrubbedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// scrubbedParams are query parameters whose values are redacted, in
+// addition to ScrubFields.
+var scrubbedParams = map[string]bool{"token": true, "access_token": true, "api_key": true, "apikey": true}
+
+// Cassette is the on-disk format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the recorded form of an HTTP request.
+type RecordedRequest struct {
+	Method       string      `json:"method"`
+	Path         string      `json:"path"`
+	Query        string      `json:"query,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// RecordedResponse is the recorded form of an HTTP response.
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records interactions to a cassette
+// file or replays them from it. Credential headers (Authorization, Cookie,
+// Set-Cookie and the like) are never written to the cassette, token query
+// parameters are replaced with Redacted, and JSON object fields named in
+// ScrubFields are replaced with Redacted in request and response bodies and
+// query parameters.
+//
+// In replay mode requests are matched by method, path, query and body; each
+// recorded interaction is used at most once, in order.
+type Recorder struct {
+	// Mode is the mode the recorder was resolved to; never ModeAuto.
+	Mode Mode
+	// Path is the cassette file.
+	Path string
+	// Transport performs real requests when recording. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// ScrubFields lists JSON field and query parameter names whose values
+	// are redacted.
+	ScrubFields []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder creates a recorder for the cassette at path. In ModeAuto it
+// replays if the cassette exists and records otherwise.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{Mode: mode, Path: path}
+	if mode == ModeAuto {
+		r.Mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.Mode = ModeReplay
+		}
+	}
+	if r.Mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &r.cassette); err != nil {
+			return nil, fmt.Errorf("contentzentest: decode cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Client returns an http.Client using the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recReq := r.recordRequest(req, body)
+	if r.Mode == ModeReplay {
+		return r.replay(req, recReq)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	recResp := RecordedResponse{StatusCode: resp.StatusCode, Header: scrubHeader(resp.Header)}
+	recResp.Body, recResp.BodyEncoding = encodeBody(r.scrub(resp.Header.Get("Content-Type"), respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recReq, Response: recResp})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the cassette file, creating its
+// directory if needed. It is a no-op in replay mode.
+func (r *Recorder) Save() error {
+	if r.Mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, append(b, '\n'), 0o644)
+}
+
+// Unused returns the recorded interactions that were not replayed, which
+// usually indicates that the code under test changed.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Interaction
+	for i, used := range r.used {
+		if !used {
+			out = append(out, r.cassette.Interactions[i])
+		}
+	}
+	return out
+}
+
+func (r *Recorder) recordRequest(req *http.Request, body []byte) RecordedRequest {
+	rec := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  r.scrubQuery(req.URL.Query()).Encode(),
+		Header: scrubHeader(req.Header),
+	}
+	rec.Body, rec.BodyEncoding = encodeBody(r.scrub(req.Header.Get("Content-Type"), body))
+	return rec
+}
+
+func (r *Recorder) replay(req *http.Request, rec RecordedRequest) (*http.Response, error) {
+	want := canonicalBody(rec.Header.Get("Content-Type"), rec.Body, rec.BodyEncoding)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+		got := in.Request
+		if got.Method != rec.Method || got.Path != rec.Path || got.Query != rec.Query {
+			continue
+		}
+		if canonicalBody(got.Header.Get("Content-Type"), got.Body, got.BodyEncoding) != want {
+			continue
+		}
+		r.used[i] = true
+		body, err := decodeBody(in.Response.Body, in.Response.BodyEncoding)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	target := rec.Path
+	if rec.Query != "" {
+		target += "?" + rec.Query
+	}
+	return nil, fmt.Errorf("contentzentest: no recorded interaction matches %s %s in %s", rec.Method, target, r.Path)
+}
+
+// scrub redacts ScrubFields in JSON bodies; other bodies are returned as is.
+func (r *Recorder) scrub(contentType string, body []byte) []byte {
+	if len(r.ScrubFields) == 0 || !isJSON(contentType) {
+		return body
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	fields := make(map[string]bool, len(r.ScrubFields))
+	for _, f := range r.ScrubFields {
+		fields[f] = true
+	}
+	out, err := json.Marshal(scrubValue(v, fields))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// scrubQuery redacts token parameters and ScrubFields in q.
+func (r *Recorder) scrubQuery(q url.Values) url.Values {
+	for k := range q {
+		if scrubbedParams[strings.ToLower(k)] || contains(r.ScrubFields, k) {
+			q[k] = []string{Redacted}
+		}
+	}
+	return q
+}
+
+// scrubHeader returns a copy of h without credential headers.
+func scrubHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range scrubbedHeaders {
+		h.Del(k)
+	}
+	return h
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func scrubValue(v interface{}, fields map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if fields[k] {
+				v[k] = Redacted
+			} else {
+				v[k] = scrubValue(val, fields)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = scrubValue(val, fields)
+		}
+	}
+	return v
+}
+
+func isJSON(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func encodeBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func decodeBody(s, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return []byte(s), nil
+}
+
+// canonicalBody normalizes a body for matching: JSON is re-encoded with
+// sorted keys and multipart bodies are reduced to their parts, since the
+// boundary is random.
+func canonicalBody(contentType, s, encoding string) string {
+	body, err := decodeBody(s, encoding)
+	if err != nil {
+		return s
+	}
+	mt, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case isJSON(contentType):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			if out, err := json.Marshal(v); err == nil {
+				return string(out)
+			}
+		}
+	case strings.HasPrefix(mt, "multipart/"):
+		var sb strings.Builder
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				return sb.String()
+			}
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(p)
+			fmt.Fprintf(&sb, "%s|%s|%s|%x\n", p.FormName(), p.FileName(), p.Header.Get("Content-Type"), data)
+		}
+	}
+	return string(body)
+}
@@ -0,0 +1,93 @@
+package contentzentest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// revisionAuthor is the author recorded for changes made through the API.
+const revisionAuthor = "api"
+
+// Revisions returns a snapshot of the revisions recorded for a document,
+// oldest first.
+func (s *Server) Revisions(collectionUUID, documentUUID string) []contentzen.Revision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, ok := s.collections[collectionUUID]
+	if !ok {
+		return nil
+	}
+	e, ok := col.documents[documentUUID]
+	if !ok {
+		return nil
+	}
+	return clone(e.revisions)
+}
+
+// save makes doc the current version of the entry, bumping its Version,
+// records it as a new revision and returns the saved document.
+func (e *documentEntry) save(doc contentzen.Document, author string) contentzen.Document {
+	doc.Version = e.document.Version + 1
+	e.document = doc
+	e.revisions = append(e.revisions, contentzen.Revision{
+		Number:    len(e.revisions) + 1,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+		Document:  clone(doc),
+	})
+	return doc
+}
+
+// lookupRevision resolves the document and revision named in the request
+// path, writing a 404 if either does not exist. The caller must hold s.mu.
+func (s *Server) lookupRevision(w http.ResponseWriter, r *http.Request) (*collectionEntry, *documentEntry, *contentzen.Revision) {
+	col, e := s.lookupDocument(w, r)
+	if e == nil {
+		return nil, nil, nil
+	}
+	n, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil || n < 1 || n > len(e.revisions) {
+		writeError(w, http.StatusNotFound, "revision not found")
+		return nil, nil, nil
+	}
+	return col, e, &e.revisions[n-1]
+}
+
+func (s *Server) listRevisions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, e := s.lookupDocument(w, r)
+	if e == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, e.revisions)
+}
+
+func (s *Server) getRevision(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _, rev := s.lookupRevision(w, r)
+	if rev == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, rev)
+}
+
+func (s *Server) restoreRevision(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	col, e, rev := s.lookupRevision(w, r)
+	if rev == nil {
+		return
+	}
+	doc := clone(rev.Document)
+	if err := col.validate(&doc); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	doc = e.save(doc, revisionAuthor)
+	writeJSON(w, http.StatusOK, doc)
+}
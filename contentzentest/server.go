@@ -0,0 +1,221 @@
+// Package contentzentest provides an in-memory ContentZen API server for
+// testing code that uses the contentzen package.
+//
+//	srv := contentzentest.NewServer()
+//	defer srv.Close()
+//	client := srv.Client()
+//	col, _ := client.CreateCollection(&contentzen.Collection{Name: "posts", IsPublic: true})
+package contentzentest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// DefaultToken is the API token accepted by a Server created with NewServer.
+const DefaultToken = "contentzentest-token"
+
+// FieldTypes are the field types reported by the fake server.
+var FieldTypes = []string{"text", "richtext", "markdown", "number", "boolean", "date", "json", "media", "reference"}
+
+// Server is an in-memory implementation of the ContentZen API backed by an
+// httptest.Server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Token is the API token required by authenticated endpoints.
+	Token string
+	// Now returns the time used to run scheduled publishes and unpublishes.
+	// Defaults to time.Now; tests can set it to move the clock forward.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	seq         int64
+	collections map[string]*collectionEntry
+	media       map[string]*mediaEntry
+	uploads     map[string]*uploadEntry
+	webhooks    map[string]*webhookEntry
+}
+
+type collectionEntry struct {
+	seq        int64
+	collection contentzen.Collection
+	documents  map[string]*documentEntry
+}
+
+type documentEntry struct {
+	seq       int64
+	document  contentzen.Document
+	revisions []contentzen.Revision
+}
+
+type mediaEntry struct {
+	seq      int64
+	media    contentzen.Media
+	filename string
+	data     []byte
+}
+
+type webhookEntry struct {
+	seq     int64
+	webhook contentzen.Webhook
+}
+
+// NewServer starts a fake ContentZen server accepting DefaultToken.
+// Callers should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		Token:       DefaultToken,
+		collections: make(map[string]*collectionEntry),
+		media:       make(map[string]*mediaEntry),
+		uploads:     make(map[string]*uploadEntry),
+		webhooks:    make(map[string]*webhookEntry),
+	}
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// Client returns a client authenticated against the server.
+func (s *Server) Client() *contentzen.Client {
+	c := contentzen.NewClient(s.Token)
+	c.BaseURL = s.URL
+	c.HTTPClient = s.Server.Client()
+	return c
+}
+
+// PublicClient returns an unauthenticated client for the server.
+func (s *Server) PublicClient() *contentzen.Client {
+	c := contentzen.NewClient("")
+	c.BaseURL = s.URL
+	c.HTTPClient = s.Server.Client()
+	return c
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/documents/collection/{collection}", s.getPublicDocuments)
+	mux.HandleFunc("GET /api/v1/documents/collection/{collection}/{document}", s.getPublicDocument)
+	mux.HandleFunc("GET /api/v1/documents/collection/{collection}/{document}/locales", s.listPublicLocales)
+	mux.HandleFunc("GET /api/v1/documents/{collection}", s.auth(s.getDocuments))
+	mux.HandleFunc("POST /api/v1/documents/{collection}", s.auth(s.createDocument))
+	mux.HandleFunc("GET /api/v1/documents/{collection}/{document}", s.auth(s.getDocument))
+	mux.HandleFunc("PUT /api/v1/documents/{collection}/{document}", s.auth(s.updateDocument))
+	mux.HandleFunc("PATCH /api/v1/documents/{collection}/{document}", s.auth(s.patchDocument))
+	mux.HandleFunc("DELETE /api/v1/documents/{collection}/{document}", s.auth(s.deleteDocument))
+	mux.HandleFunc("POST /api/v1/documents/{collection}/{document}/publish", s.auth(s.transitionDocument("", contentzen.StatePublished)))
+	mux.HandleFunc("POST /api/v1/documents/{collection}/{document}/unpublish", s.auth(s.transitionDocument(contentzen.StatePublished, contentzen.StateDraft)))
+	mux.HandleFunc("POST /api/v1/documents/{collection}/{document}/archive", s.auth(s.transitionDocument("", contentzen.StateArchived)))
+	mux.HandleFunc("POST /api/v1/documents/{collection}/{document}/unarchive", s.auth(s.transitionDocument(contentzen.StateArchived, contentzen.StateDraft)))
+	mux.HandleFunc("POST /api/v1/documents/{collection}/{document}/schedule", s.auth(s.scheduleDocument))
+	mux.HandleFunc("GET /api/v1/locales/{collection}/{document}", s.auth(s.listLocales))
+	mux.HandleFunc("PUT /api/v1/locales/{collection}/{document}/{lang}", s.auth(s.putLocale))
+	mux.HandleFunc("GET /api/v1/revisions/{collection}/{document}", s.auth(s.listRevisions))
+	mux.HandleFunc("GET /api/v1/revisions/{collection}/{document}/{revision}", s.auth(s.getRevision))
+	mux.HandleFunc("POST /api/v1/revisions/{collection}/{document}/{revision}/restore", s.auth(s.restoreRevision))
+
+	mux.HandleFunc("GET /api/v1/collections", s.auth(s.getCollections))
+	mux.HandleFunc("POST /api/v1/collections", s.auth(s.createCollection))
+	mux.HandleFunc("GET /api/v1/collections/field-types", s.auth(s.getFieldTypes))
+	mux.HandleFunc("GET /api/v1/collections/{collection}", s.auth(s.getCollection))
+	mux.HandleFunc("PUT /api/v1/collections/{collection}", s.auth(s.updateCollection))
+	mux.HandleFunc("DELETE /api/v1/collections/{collection}", s.auth(s.deleteCollection))
+	mux.HandleFunc("GET /api/v1/collections/{collection}/schema", s.auth(s.getCollectionSchema))
+	mux.HandleFunc("GET /api/v1/collections/{collection}/fields", s.auth(s.getCollectionFields))
+
+	mux.HandleFunc("GET /api/v1/media", s.auth(s.listMediaPage))
+	mux.HandleFunc("GET /api/v1/media/ls", s.auth(s.listMedia))
+	mux.HandleFunc("POST /api/v1/media/upload", s.auth(s.uploadMedia))
+	mux.HandleFunc("GET /api/v1/media/{media}", s.auth(s.getMedia))
+	mux.HandleFunc("PUT /api/v1/media/{media}", s.auth(s.updateMedia))
+	mux.HandleFunc("DELETE /api/v1/media/{media}", s.auth(s.deleteMedia))
+	mux.HandleFunc("GET /api/v1/media/{media}/download", s.auth(s.downloadMedia))
+	mux.HandleFunc("GET /files/{media}/{filename}", s.serveFile)
+	mux.HandleFunc("POST /api/v1/uploads", s.auth(s.initiateUpload))
+	mux.HandleFunc("GET /api/v1/uploads/{upload}", s.auth(s.getUpload))
+	mux.HandleFunc("DELETE /api/v1/uploads/{upload}", s.auth(s.abortUpload))
+	mux.HandleFunc("PUT /api/v1/uploads/{upload}/parts/{part}", s.auth(s.uploadPart))
+	mux.HandleFunc("POST /api/v1/uploads/{upload}/complete", s.auth(s.completeUpload))
+
+	mux.HandleFunc("GET /api/v1/webhooks", s.auth(s.listWebhooks))
+	mux.HandleFunc("POST /api/v1/webhooks", s.auth(s.createWebhook))
+	mux.HandleFunc("PUT /api/v1/webhooks/{webhook}", s.auth(s.updateWebhook))
+	mux.HandleFunc("DELETE /api/v1/webhooks/{webhook}", s.auth(s.deleteWebhook))
+
+	return s.applySchedules(mux)
+}
+
+// auth rejects requests that do not carry the server's Bearer token.
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, "missing API token")
+			return
+		}
+		if token != s.Token {
+			writeError(w, http.StatusForbidden, "invalid API token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// nextSeq returns a monotonically increasing sequence number used to keep
+// listings in creation order. The caller must hold s.mu.
+func (s *Server) nextSeq() int64 {
+	s.seq++
+	return s.seq
+}
+
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ifMatch reports whether the request's If-Match header, if any, matches
+// the current version of a resource.
+func ifMatch(r *http.Request, version int64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == contentzen.ETag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package contentzentest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// minPartSize is the smallest part size accepted for all but the last part.
+const minPartSize = 1 << 10
+
+type uploadEntry struct {
+	upload      contentzen.MultipartUpload
+	filename    string
+	contentType string
+	size        int64
+	parts       map[int][]byte
+}
+
+func (e *uploadEntry) snapshot() contentzen.MultipartUpload {
+	up := e.upload
+	up.Parts = []contentzen.UploadedPart{}
+	for n, data := range e.parts {
+		up.Parts = append(up.Parts, contentzen.UploadedPart{PartNumber: n, ETag: etag(data), Size: int64(len(data))})
+	}
+	sort.Slice(up.Parts, func(i, j int) bool { return up.Parts[i].PartNumber < up.Parts[j].PartNumber })
+	return up
+}
+
+func etag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) initiateUpload(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filename    string `json:"filename"`
+		ContentType string `json:"content_type"`
+		Size        int64  `json:"size"`
+		PartSize    int64  `json:"part_size"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Filename == "" || req.Size <= 0 {
+		writeError(w, http.StatusUnprocessableEntity, "filename and size are required")
+		return
+	}
+	if req.PartSize < minPartSize {
+		req.PartSize = minPartSize
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := &uploadEntry{
+		upload:      contentzen.MultipartUpload{UploadID: newUUID(), PartSize: req.PartSize},
+		filename:    req.Filename,
+		contentType: req.ContentType,
+		size:        req.Size,
+		parts:       make(map[int][]byte),
+	}
+	s.uploads[e.upload.UploadID] = e
+	writeJSON(w, http.StatusCreated, e.snapshot())
+}
+
+func (s *Server) getUpload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.uploads[r.PathValue("upload")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e.snapshot())
+}
+
+func (s *Server) abortUpload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("upload")
+	if _, ok := s.uploads[id]; !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	delete(s.uploads, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("part"))
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, "invalid part number")
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.uploads[r.PathValue("upload")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	if int64(len(data)) > e.upload.PartSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "part exceeds part size")
+		return
+	}
+	e.parts[n] = data
+	writeJSON(w, http.StatusOK, contentzen.UploadedPart{PartNumber: n, ETag: etag(data), Size: int64(len(data))})
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Parts   []contentzen.UploadedPart `json:"parts"`
+		AltText string                    `json:"alt_text"`
+		Tags    []string                  `json:"tags"`
+		Folder  string                    `json:"folder"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("upload")
+	e, ok := s.uploads[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	var buf bytes.Buffer
+	for i, p := range req.Parts {
+		data, ok := e.parts[p.PartNumber]
+		if p.PartNumber != i+1 || !ok || etag(data) != p.ETag {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("part %d is missing or does not match", i+1))
+			return
+		}
+		if i < len(req.Parts)-1 && int64(len(data)) != e.upload.PartSize {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("part %d is smaller than the part size", p.PartNumber))
+			return
+		}
+		buf.Write(data)
+	}
+	if int64(buf.Len()) != e.size {
+		writeError(w, http.StatusUnprocessableEntity, "assembled size does not match the declared size")
+		return
+	}
+	delete(s.uploads, id)
+	m := s.storeMedia(contentzen.Media{AltText: req.AltText, Tags: req.Tags, Folder: req.Folder, MimeType: e.contentType}, e.filename, buf.Bytes())
+	writeJSON(w, http.StatusCreated, m)
+}
@@ -0,0 +1,92 @@
+package contentzentest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+func validateWebhook(wh *contentzen.Webhook) string {
+	if wh.Name == "" {
+		return "name is required"
+	}
+	if u, err := url.Parse(wh.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "url must be an absolute http(s) URL"
+	}
+	if len(wh.Events) == 0 {
+		return "at least one event is required"
+	}
+	return ""
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*webhookEntry, 0, len(s.webhooks))
+	for _, e := range s.webhooks {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	webhooks := make([]contentzen.Webhook, 0, len(entries))
+	for _, e := range entries {
+		webhooks = append(webhooks, e.webhook)
+	}
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var wh contentzen.Webhook
+	if !decodeJSON(w, r, &wh) {
+		return
+	}
+	if msg := validateWebhook(&wh); msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	if wh.Method == "" {
+		wh.Method = http.MethodPost
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wh.UUID = newUUID()
+	s.webhooks[wh.UUID] = &webhookEntry{seq: s.nextSeq(), webhook: wh}
+	writeJSON(w, http.StatusCreated, wh)
+}
+
+func (s *Server) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	var wh contentzen.Webhook
+	if !decodeJSON(w, r, &wh) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.webhooks[r.PathValue("webhook")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	if msg := validateWebhook(&wh); msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, msg)
+		return
+	}
+	if wh.Method == "" {
+		wh.Method = e.webhook.Method
+	}
+	wh.UUID = e.webhook.UUID
+	e.webhook = wh
+	writeJSON(w, http.StatusOK, wh)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uuid := r.PathValue("webhook")
+	if _, ok := s.webhooks[uuid]; !ok {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	delete(s.webhooks, uuid)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,209 @@
+// Command mockgen generates call-recording mocks for the service interfaces
+// declared in a source file of the contentzen package.
+//
+// For every interface named *Service it emits a Mock<Name> struct with one
+// <Method>Func field per method. Calls are recorded before the func field is
+// invoked; methods whose func field is nil return zero values.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const modulePath = "github.com/contentzen-hub/sdk-go"
+
+func main() {
+	source := flag.String("source", "", "Go file declaring the service interfaces")
+	out := flag.String("out", "", "output file")
+	pkg := flag.String("pkg", "", "package name of the output file")
+	flag.Parse()
+	if *source == "" || *out == "" || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, *source, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srcPkg := file.Name.Name
+	srcDir, err := filepath.Abs(filepath.Dir(*source))
+	if err != nil {
+		log.Fatal(err)
+	}
+	srcImport := modulePath + "/" + filepath.Base(srcDir)
+
+	imports := map[string]string{srcPkg: srcImport}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	used := map[string]bool{srcPkg: true}
+
+	var body bytes.Buffer
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok || !strings.HasSuffix(ts.Name.Name, "Service") {
+				continue
+			}
+			writeMock(&body, fset, srcPkg, ts.Name.Name, iface, used)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by internal/mockgen from %s/%s. DO NOT EDIT.\n\n", srcPkg, filepath.Base(*source))
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n", *pkg)
+	var names []string
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return imports[names[i]] < imports[names[j]] })
+	for _, std := range []bool{true, false} {
+		for _, name := range names {
+			if isStdlib(imports[name]) == std {
+				fmt.Fprintf(&buf, "\t%q\n", imports[name])
+			}
+		}
+		if std {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("format generated code: %v\n%s", err, buf.Bytes())
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// isStdlib reports whether path is a standard library import path.
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func writeMock(w *bytes.Buffer, fset *token.FileSet, srcPkg, name string, iface *ast.InterfaceType, used map[string]bool) {
+	mock := "Mock" + name
+	fmt.Fprintf(w, "\n// %s is a mock %s.%s that records calls.\ntype %s struct {\n\tcallRecorder\n\n", mock, srcPkg, name, mock)
+	for _, m := range iface.Methods.List {
+		fn := m.Type.(*ast.FuncType)
+		fmt.Fprintf(w, "\t%sFunc func%s\n", m.Names[0].Name, typeString(fset, srcPkg, fn, used)[len("func"):])
+	}
+	fmt.Fprintf(w, "}\n\nvar _ %s.%s = (*%s)(nil)\n", srcPkg, name, mock)
+
+	for _, m := range iface.Methods.List {
+		method := m.Names[0].Name
+		fn := m.Type.(*ast.FuncType)
+		var params, args, callArgs []string
+		for i, field := range fn.Params.List {
+			typ := typeString(fset, srcPkg, field.Type, used)
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+			}
+			for _, n := range names {
+				params = append(params, n.Name+" "+typ)
+				args = append(args, n.Name)
+				if _, ok := field.Type.(*ast.Ellipsis); ok {
+					callArgs = append(callArgs, n.Name+"...")
+				} else {
+					callArgs = append(callArgs, n.Name)
+				}
+			}
+		}
+		var results []string
+		if fn.Results != nil {
+			for i, field := range fn.Results.List {
+				results = append(results, fmt.Sprintf("r%d %s", i, typeString(fset, srcPkg, field.Type, used)))
+			}
+		}
+		fmt.Fprintf(w, "\n// %s records the call and invokes %sFunc if set.\n", method, method)
+		fmt.Fprintf(w, "func (m *%s) %s(%s) (%s) {\n", mock, method, strings.Join(params, ", "), strings.Join(results, ", "))
+		fmt.Fprintf(w, "\tm.record(%q", method)
+		for _, a := range args {
+			fmt.Fprintf(w, ", %s", a)
+		}
+		fmt.Fprintf(w, ")\n\tif m.%sFunc != nil {\n\t\t", method)
+		if len(results) > 0 {
+			w.WriteString("return ")
+		}
+		fmt.Fprintf(w, "m.%sFunc(%s)\n", method, strings.Join(callArgs, ", "))
+		if len(results) == 0 {
+			w.WriteString("\t\treturn\n")
+		}
+		w.WriteString("\t}\n\treturn\n}\n")
+	}
+}
+
+// typeString prints expr with exported identifiers of the source package
+// qualified by its name, recording the packages it refers to in used.
+func typeString(fset *token.FileSet, srcPkg string, expr ast.Expr, used map[string]bool) string {
+	expr = qualify(srcPkg, expr, used)
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+func qualify(srcPkg string, expr ast.Expr, used map[string]bool) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(e.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent(srcPkg), Sel: ast.NewIdent(e.Name)}
+		}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			used[x.Name] = true
+		}
+	case *ast.StarExpr:
+		e.X = qualify(srcPkg, e.X, used)
+	case *ast.ArrayType:
+		e.Elt = qualify(srcPkg, e.Elt, used)
+	case *ast.MapType:
+		e.Key = qualify(srcPkg, e.Key, used)
+		e.Value = qualify(srcPkg, e.Value, used)
+	case *ast.ChanType:
+		e.Value = qualify(srcPkg, e.Value, used)
+	case *ast.Ellipsis:
+		e.Elt = qualify(srcPkg, e.Elt, used)
+	case *ast.FuncType:
+		for _, list := range []*ast.FieldList{e.Params, e.Results} {
+			if list == nil {
+				continue
+			}
+			for _, f := range list.List {
+				f.Type = qualify(srcPkg, f.Type, used)
+			}
+		}
+	}
+	return expr
+}
@@ -0,0 +1,257 @@
+package richtext
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// HTMLRenderer renders rich text to sanitized HTML. Text and attribute
+// values are escaped, only known node and mark types produce tags, and
+// URLs with a scheme other than http, https, mailto or tel are dropped.
+// The zero value is ready to use.
+type HTMLRenderer struct {
+	// Media renders Media nodes; its output is inserted as is. By default
+	// a media node is rendered as an <img> using its "url" and "alt"
+	// attributes, and omitted if it has no URL.
+	Media func(n *Node) (template.HTML, error)
+	// DocumentURL returns the URL of a linked document. Without it, links
+	// to documents are rendered as their text only.
+	DocumentURL func(collectionUUID, documentUUID string) (string, error)
+}
+
+// RenderHTML renders n to HTML with the default HTMLRenderer.
+func RenderHTML(n *Node) (template.HTML, error) {
+	return (&HTMLRenderer{}).Render(n)
+}
+
+// Render renders n to HTML.
+func (r *HTMLRenderer) Render(n *Node) (template.HTML, error) {
+	w := &htmlWriter{r: r}
+	w.content([]*Node{n})
+	if w.err != nil {
+		return "", w.err
+	}
+	return template.HTML(w.sb.String()), nil
+}
+
+type htmlWriter struct {
+	r   *HTMLRenderer
+	sb  strings.Builder
+	err error
+	// links records, for each open link mark, whether an <a> was written.
+	links []bool
+}
+
+// content renders a list of sibling nodes, grouping runs of inline nodes.
+func (w *htmlWriter) content(nodes []*Node) {
+	for i := 0; i < len(nodes); {
+		if isInline(nodes[i].Type) {
+			j := i
+			for j < len(nodes) && isInline(nodes[j].Type) {
+				j++
+			}
+			w.inline(nodes[i:j])
+			i = j
+			continue
+		}
+		w.block(nodes[i])
+		i++
+	}
+}
+
+func (w *htmlWriter) block(n *Node) {
+	switch n.Type {
+	case Paragraph:
+		w.wrap("<p>", n, "</p>")
+	case Heading:
+		level := min(max(intAttr(n.Attrs, "level", 1), 1), 6)
+		w.wrap(fmt.Sprintf("<h%d>", level), n, fmt.Sprintf("</h%d>", level))
+	case Blockquote:
+		w.wrap("<blockquote>", n, "</blockquote>")
+	case BulletList:
+		w.wrap("<ul>", n, "</ul>")
+	case OrderedList:
+		if start := intAttr(n.Attrs, "start", 1); start != 1 {
+			w.wrap(fmt.Sprintf(`<ol start="%d">`, start), n, "</ol>")
+		} else {
+			w.wrap("<ol>", n, "</ol>")
+		}
+	case ListItem:
+		w.wrap("<li>", n, "</li>")
+	case CodeBlock:
+		w.sb.WriteString("<pre><code")
+		if lang := codeLanguage(n.Attr("language")); lang != "" {
+			fmt.Fprintf(&w.sb, ` class="language-%s"`, lang)
+		}
+		w.sb.WriteString(">")
+		w.sb.WriteString(html.EscapeString(n.textContent()))
+		w.sb.WriteString("</code></pre>")
+	case HorizontalRule:
+		w.sb.WriteString("<hr>")
+	case Media:
+		w.media(n)
+	default:
+		w.content(n.Content)
+	}
+}
+
+func (w *htmlWriter) wrap(open string, n *Node, close string) {
+	w.sb.WriteString(open)
+	w.content(n.Content)
+	w.sb.WriteString(close)
+}
+
+// inline renders a run of inline nodes, opening and closing mark tags only
+// where the marks change.
+func (w *htmlWriter) inline(nodes []*Node) {
+	span := &markSpan{start: w.markStart, end: w.markEnd}
+	for _, n := range nodes {
+		w.sb.WriteString(span.next(n.Marks))
+		switch n.Type {
+		case Text:
+			w.sb.WriteString(html.EscapeString(n.Text))
+		case HardBreak:
+			w.sb.WriteString("<br>")
+		case Image:
+			w.image(n.Attr("src"), n.Attr("alt"), n.Attr("title"))
+		}
+	}
+	w.sb.WriteString(span.next(nil))
+}
+
+func (w *htmlWriter) markStart(m Mark) string {
+	switch m.Type {
+	case Bold:
+		return "<strong>"
+	case Italic:
+		return "<em>"
+	case Underline:
+		return "<u>"
+	case Strike:
+		return "<s>"
+	case Code:
+		return "<code>"
+	case Link:
+		href := w.linkURL(m)
+		w.links = append(w.links, href != "")
+		if href == "" {
+			return ""
+		}
+		if title := m.Attr("title"); title != "" {
+			return fmt.Sprintf(`<a href="%s" title="%s">`, html.EscapeString(href), html.EscapeString(title))
+		}
+		return fmt.Sprintf(`<a href="%s">`, html.EscapeString(href))
+	}
+	return ""
+}
+
+func (w *htmlWriter) markEnd(m Mark) string {
+	switch m.Type {
+	case Bold:
+		return "</strong>"
+	case Italic:
+		return "</em>"
+	case Underline:
+		return "</u>"
+	case Strike:
+		return "</s>"
+	case Code:
+		return "</code>"
+	case Link:
+		written := w.links[len(w.links)-1]
+		w.links = w.links[:len(w.links)-1]
+		if !written {
+			return ""
+		}
+		return "</a>"
+	}
+	return ""
+}
+
+func (w *htmlWriter) linkURL(m Mark) string {
+	u, err := resolveLink(m, w.r.DocumentURL)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return u
+}
+
+func (w *htmlWriter) image(src, alt, title string) {
+	src = safeURL(src)
+	if src == "" {
+		return
+	}
+	fmt.Fprintf(&w.sb, `<img src="%s" alt="%s"`, html.EscapeString(src), html.EscapeString(alt))
+	if title != "" {
+		fmt.Fprintf(&w.sb, ` title="%s"`, html.EscapeString(title))
+	}
+	w.sb.WriteString(">")
+}
+
+func (w *htmlWriter) media(n *Node) {
+	if w.r.Media == nil {
+		w.image(n.Attr("url"), n.Attr("alt"), "")
+		return
+	}
+	out, err := w.r.Media(n)
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.sb.WriteString(string(out))
+}
+
+func isInline(typ string) bool {
+	return typ == Text || typ == HardBreak || typ == Image
+}
+
+// resolveLink returns the sanitized URL of a link mark, resolving links to
+// documents with documentURL.
+func resolveLink(m Mark, documentURL func(string, string) (string, error)) (string, error) {
+	if doc := m.Attr("document"); doc != "" {
+		if documentURL == nil {
+			return "", nil
+		}
+		u, err := documentURL(m.Attr("collection"), doc)
+		if err != nil {
+			return "", fmt.Errorf("richtext: resolve link to document %s: %w", doc, err)
+		}
+		return safeURL(u), nil
+	}
+	return safeURL(m.Attr("href")), nil
+}
+
+// safeURL returns u if it is relative or uses a harmless scheme, and ""
+// otherwise.
+func safeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return ""
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto", "tel":
+		return u
+	}
+	return ""
+}
+
+var codeLanguageRE = regexp.MustCompile(`^[A-Za-z0-9_+#.-]+$`)
+
+// codeLanguage returns lang if it is safe to use as a class name suffix
+// and info string.
+func codeLanguage(lang string) string {
+	if codeLanguageRE.MatchString(lang) {
+		return lang
+	}
+	return ""
+}
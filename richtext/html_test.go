@@ -0,0 +1,98 @@
+package richtext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"http://example.com", "http://example.com"},
+		{"HTTPS://example.com", "HTTPS://example.com"},
+		{"mailto:a@example.com", "mailto:a@example.com"},
+		{"tel:+123", "tel:+123"},
+		{"/about/", "/about/"},
+		{"../about", "../about"},
+		{"#top", "#top"},
+		{"?page=2", "?page=2"},
+		{"  https://example.com  ", "https://example.com"},
+		{"javascript:alert(1)", ""},
+		{"JavaScript:alert(1)", ""},
+		{"JAVASCRIPT:alert(1)", ""},
+		{" javascript:alert(1)", ""},
+		{"\tjavascript:alert(1)", ""},
+		{"\njavascript:alert(1)", ""},
+		{" javascript:alert(1)", ""},
+		{"java\tscript:alert(1)", ""},
+		{"java\nscript:alert(1)", ""},
+		{"java\x00script:alert(1)", ""},
+		{"vbscript:msgbox(1)", ""},
+		{"data:text/html,<script>alert(1)</script>", ""},
+		{"DATA:image/png;base64,AAAA", ""},
+		{"file:///etc/passwd", ""},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.in); got != tt.want {
+			t.Errorf("safeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHTMLEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "text",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"<script>alert(\"x\") & 'y'</script>"}]}`,
+			want: `<p>&lt;script&gt;alert(&#34;x&#34;) &amp; &#39;y&#39;&lt;/script&gt;</p>`,
+		},
+		{
+			name: "link attributes",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"a","marks":[{"type":"link","attrs":{"href":"/x?a=1&b=\"2\"","title":"\" onmouseover=\"alert(1)"}}]}]}`,
+			want: `<p><a href="/x?a=1&amp;b=&#34;2&#34;" title="&#34; onmouseover=&#34;alert(1)">a</a></p>`,
+		},
+		{
+			name: "unsafe link",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"a","marks":[{"type":"link","attrs":{"href":" JavaScript:alert(1)"}}]}]}`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "image attributes",
+			doc:  `{"type":"paragraph","content":[{"type":"image","attrs":{"src":"/i.png","alt":"\"><script>","title":"<t>"}}]}`,
+			want: `<p><img src="/i.png" alt="&#34;&gt;&lt;script&gt;" title="&lt;t&gt;"></p>`,
+		},
+		{
+			name: "unsafe image",
+			doc:  `{"type":"paragraph","content":[{"type":"image","attrs":{"src":"data:image/svg+xml,<svg onload=alert(1)>"}}]}`,
+			want: `<p></p>`,
+		},
+		{
+			name: "code language",
+			doc:  `{"type":"code_block","attrs":{"language":"go\" onclick=\"x"},"content":[{"type":"text","text":"a < b"}]}`,
+			want: `<pre><code>a &lt; b</code></pre>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := RenderHTML(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(got)) != tt.want {
+				t.Errorf("RenderHTML = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
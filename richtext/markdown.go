@@ -0,0 +1,320 @@
+package richtext
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownRenderer renders rich text to CommonMark. Text is escaped, so
+// that it is not interpreted as Markdown syntax, and unsafe URLs are
+// dropped as with HTMLRenderer. Underline has no Markdown equivalent and is
+// rendered as plain text. The zero value is ready to use.
+type MarkdownRenderer struct {
+	// Media renders Media nodes; its output is inserted as is. By default
+	// a media node is rendered as an image using its "url" and "alt"
+	// attributes, and omitted if it has no URL.
+	Media func(n *Node) (string, error)
+	// DocumentURL returns the URL of a linked document. Without it, links
+	// to documents are rendered as their text only.
+	DocumentURL func(collectionUUID, documentUUID string) (string, error)
+}
+
+// RenderMarkdown renders n to Markdown with the default MarkdownRenderer.
+func RenderMarkdown(n *Node) (string, error) {
+	return (&MarkdownRenderer{}).Render(n)
+}
+
+// Render renders n to Markdown. The result ends with a newline unless it is
+// empty.
+func (r *MarkdownRenderer) Render(n *Node) (string, error) {
+	w := &markdownWriter{r: r}
+	out := w.content([]*Node{n}, "\n\n")
+	if w.err != nil {
+		return "", w.err
+	}
+	if out == "" {
+		return "", nil
+	}
+	return out + "\n", nil
+}
+
+type markdownWriter struct {
+	r   *MarkdownRenderer
+	err error
+	// links holds, for each open link mark, the text closing it.
+	links []string
+}
+
+func (w *markdownWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// content renders a list of sibling nodes, grouping runs of inline nodes,
+// and joins the resulting blocks with sep.
+func (w *markdownWriter) content(nodes []*Node, sep string) string {
+	var blocks []string
+	for i := 0; i < len(nodes); {
+		var out string
+		if isInline(nodes[i].Type) {
+			j := i
+			for j < len(nodes) && isInline(nodes[j].Type) {
+				j++
+			}
+			out = escapeBlockStart(w.inline(nodes[i:j]))
+			i = j
+		} else {
+			out = w.block(nodes[i])
+			i++
+		}
+		if out != "" {
+			blocks = append(blocks, out)
+		}
+	}
+	return strings.Join(blocks, sep)
+}
+
+func (w *markdownWriter) block(n *Node) string {
+	switch n.Type {
+	case Paragraph:
+		return escapeBlockStart(w.inline(n.Content))
+	case Heading:
+		level := min(max(intAttr(n.Attrs, "level", 1), 1), 6)
+		text := strings.ReplaceAll(w.inline(n.Content), "\\\n", " ")
+		return strings.Repeat("#", level) + " " + text
+	case Blockquote:
+		return prefixLines(w.content(n.Content, "\n\n"), "> ")
+	case BulletList:
+		items := make([]string, 0, len(n.Content))
+		for _, item := range n.Content {
+			items = append(items, listItem("- ", w.content(item.Content, "\n\n")))
+		}
+		return strings.Join(items, "\n")
+	case OrderedList:
+		start := intAttr(n.Attrs, "start", 1)
+		items := make([]string, 0, len(n.Content))
+		for i, item := range n.Content {
+			marker := strconv.Itoa(start+i) + ". "
+			items = append(items, listItem(marker, w.content(item.Content, "\n\n")))
+		}
+		return strings.Join(items, "\n")
+	case CodeBlock:
+		code := strings.TrimSuffix(n.textContent(), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + codeLanguage(n.Attr("language")) + "\n" + code + "\n" + fence
+	case HorizontalRule:
+		return "---"
+	case Media:
+		return w.media(n)
+	}
+	return w.content(n.Content, "\n\n")
+}
+
+// inline renders a run of inline nodes, opening and closing mark delimiters
+// only where the marks change.
+func (w *markdownWriter) inline(nodes []*Node) string {
+	var sb strings.Builder
+	span := &markSpan{start: w.markStart, end: w.markEnd}
+	for _, n := range mergeText(nodes) {
+		sb.WriteString(span.next(n.Marks))
+		switch n.Type {
+		case Text:
+			if hasMark(n.Marks, Code) {
+				sb.WriteString(codeSpan(n.Text))
+			} else {
+				sb.WriteString(escapeMarkdown(n.Text))
+			}
+		case HardBreak:
+			sb.WriteString("\\\n")
+		case Image:
+			sb.WriteString(image(n.Attr("src"), n.Attr("alt"), n.Attr("title")))
+		}
+	}
+	sb.WriteString(span.next(nil))
+	return sb.String()
+}
+
+func (w *markdownWriter) markStart(m Mark) string {
+	switch m.Type {
+	case Bold:
+		return "**"
+	case Italic:
+		return "_"
+	case Strike:
+		return "~~"
+	case Link:
+		href, err := resolveLink(m, w.r.DocumentURL)
+		if err != nil {
+			w.fail(err)
+		}
+		if href == "" {
+			w.links = append(w.links, "")
+			return ""
+		}
+		w.links = append(w.links, "]("+linkDestination(href, m.Attr("title"))+")")
+		return "["
+	}
+	return ""
+}
+
+func (w *markdownWriter) markEnd(m Mark) string {
+	switch m.Type {
+	case Bold:
+		return "**"
+	case Italic:
+		return "_"
+	case Strike:
+		return "~~"
+	case Link:
+		end := w.links[len(w.links)-1]
+		w.links = w.links[:len(w.links)-1]
+		return end
+	}
+	return ""
+}
+
+func (w *markdownWriter) media(n *Node) string {
+	if w.r.Media == nil {
+		return image(n.Attr("url"), n.Attr("alt"), "")
+	}
+	out, err := w.r.Media(n)
+	if err != nil {
+		w.fail(err)
+		return ""
+	}
+	return out
+}
+
+func image(src, alt, title string) string {
+	src = safeURL(src)
+	if src == "" {
+		return ""
+	}
+	return "![" + escapeMarkdown(alt) + "](" + linkDestination(src, title) + ")"
+}
+
+// linkDestination formats a link destination and optional title.
+func linkDestination(href, title string) string {
+	href = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(href)
+	if title == "" {
+		return href
+	}
+	return fmt.Sprintf("%s %q", href, title)
+}
+
+// mergeText joins adjacent text nodes with the same marks, so that each
+// code span is written once.
+func mergeText(nodes []*Node) []*Node {
+	merged := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if last := len(merged) - 1; last >= 0 && n.Type == Text && merged[last].Type == Text && sameMarks(merged[last].Marks, n.Marks) {
+			joined := *merged[last]
+			joined.Text += n.Text
+			merged[last] = &joined
+			continue
+		}
+		merged = append(merged, n)
+	}
+	return merged
+}
+
+func sameMarks(a, b []Mark) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameMark(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// codeSpan formats a code span, using a backtick string longer than any in
+// s as its delimiter.
+func codeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+func hasMark(marks []Mark, typ string) bool {
+	for _, m := range marks {
+		if m.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `~`, `\~`, `|`, `\|`, `&`, `\&`,
+)
+
+// escapeMarkdown escapes the characters of s that Markdown would interpret
+// as inline syntax. A trailing "!" is escaped too, since a link rendered
+// right after it would otherwise become an image.
+func escapeMarkdown(s string) string {
+	s = markdownEscaper.Replace(s)
+	if strings.HasSuffix(s, "!") {
+		s = s[:len(s)-1] + `\!`
+	}
+	return s
+}
+
+var blockStartRE = regexp.MustCompile(`^(\s*)([-+=]|\d+[.)])`)
+
+// escapeBlockStart escapes a leading list marker or setext underline, which
+// would otherwise turn a paragraph into a list or heading.
+func escapeBlockStart(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if m := blockStartRE.FindStringSubmatchIndex(line); m != nil {
+			end := m[5] - 1
+			lines[i] = line[:end] + `\` + line[end:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes every line of s, trimming trailing space from the
+// prefix on blank lines.
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listItem renders a list item, indenting its continuation lines to the
+// width of the marker.
+func listItem(marker, s string) string {
+	pad := strings.Repeat(" ", len(marker))
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = pad + lines[i]
+		}
+	}
+	if s == "" {
+		return strings.TrimRight(marker, " ")
+	}
+	return marker + strings.Join(lines, "\n")
+}
@@ -0,0 +1,110 @@
+package richtext
+
+import (
+	"testing"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"*bold* and _italic_", `\*bold\* and \_italic\_`},
+		{"[link](javascript:alert(1))", `\[link\](javascript:alert(1))`},
+		{"`code`", "\\`code\\`"},
+		{`a\b`, `a\\b`},
+		{"<script>", `\<script\>`},
+		{"# not a heading", `\# not a heading`},
+		{"~~strike~~", `\~\~strike\~\~`},
+		{"a | b", `a \| b`},
+		{"&copy; &#169;", `\&copy; \&\#169;`},
+		{"Look!", `Look\!`},
+		{"Hi! ![x]", `Hi! !\[x\]`},
+	}
+	for _, tt := range tests {
+		if got := escapeMarkdown(tt.in); got != tt.want {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeBlockStart(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"text", "text"},
+		{"- item", `\- item`},
+		{"+ item", `\+ item`},
+		{"===", `\===`},
+		{"1. item", `1\. item`},
+		{"12) item", `12\) item`},
+		{"  - item", `  \- item`},
+		{"a\n- b", "a\n\\- b"},
+		{"a - b", "a - b"},
+		{"2024 was", "2024 was"},
+	}
+	for _, tt := range tests {
+		if got := escapeBlockStart(tt.in); got != tt.want {
+			t.Errorf("escapeBlockStart(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdownEscaping(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "text",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"1. *not* a list"}]}`,
+			want: "1\\. \\*not\\* a list\n",
+		},
+		{
+			name: "link",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"a]b","marks":[{"type":"link","attrs":{"href":"/x y)","title":"say \"hi\""}}]}]}`,
+			want: "[a\\]b](/x%20y%29 \"say \\\"hi\\\"\")\n",
+		},
+		{
+			name: "unsafe link",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"a","marks":[{"type":"link","attrs":{"href":"javascript:alert(1)"}}]}]}`,
+			want: "a\n",
+		},
+		{
+			name: "image",
+			doc:  `{"type":"paragraph","content":[{"type":"image","attrs":{"src":"/i.png","alt":"[x]"}}]}`,
+			want: "![\\[x\\]](/i.png)\n",
+		},
+		{
+			name: "unsafe image",
+			doc:  `{"type":"paragraph","content":[{"type":"image","attrs":{"src":"data:image/png;base64,AAAA"}}]}`,
+			want: "",
+		},
+		{
+			name: "bang before link",
+			doc:  `{"type":"paragraph","content":[{"type":"text","text":"Look!"},{"type":"text","text":"x","marks":[{"type":"link","attrs":{"href":"https://e.com/a.png"}}]}]}`,
+			want: "Look\\![x](https://e.com/a.png)\n",
+		},
+		{
+			name: "code span",
+			doc:  "{\"type\":\"paragraph\",\"content\":[{\"type\":\"text\",\"text\":\"a`b\",\"marks\":[{\"type\":\"code\"}]}]}",
+			want: "``a`b``\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := RenderMarkdown(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderMarkdown = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
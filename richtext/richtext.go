@@ -0,0 +1,216 @@
+// Package richtext parses and renders ContentZen rich-text fields.
+//
+// A rich-text field is stored in a document's payload as a tree of nodes:
+//
+//	{"type": "doc", "content": [
+//		{"type": "paragraph", "content": [
+//			{"type": "text", "text": "Hello "},
+//			{"type": "text", "text": "world", "marks": [{"type": "bold"}]}
+//		]}
+//	]}
+//
+// Parse turns such a value into a *Node tree, which HTMLRenderer and
+// MarkdownRenderer render. Unknown node and mark types are rendered as
+// their content only, so newer editor features degrade gracefully.
+package richtext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Node types.
+const (
+	Doc            = "doc"
+	Paragraph      = "paragraph"
+	Heading        = "heading"
+	Blockquote     = "blockquote"
+	BulletList     = "bullet_list"
+	OrderedList    = "ordered_list"
+	ListItem       = "list_item"
+	CodeBlock      = "code_block"
+	HorizontalRule = "horizontal_rule"
+	HardBreak      = "hard_break"
+	Image          = "image"
+	Text           = "text"
+	// Media embeds a file from the media library by its "uuid" attribute.
+	Media = "media"
+)
+
+// Mark types.
+const (
+	Bold      = "bold"
+	Italic    = "italic"
+	Underline = "underline"
+	Strike    = "strike"
+	Code      = "code"
+	// Link marks link to the URL in their "href" attribute, or to a
+	// document given by their "collection" and "document" attributes.
+	Link = "link"
+)
+
+// ErrInvalid is returned by Parse for values that are not a rich-text tree.
+var ErrInvalid = errors.New("richtext: invalid rich text")
+
+// Node is a node of a rich-text tree.
+type Node struct {
+	Type    string                 `json:"type"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*Node                `json:"content,omitempty"`
+	// Text is the text of a Text node.
+	Text  string `json:"text,omitempty"`
+	Marks []Mark `json:"marks,omitempty"`
+}
+
+// Mark is inline formatting applied to a Text node.
+type Mark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Parse parses the JSON encoding of a rich-text tree.
+func Parse(data []byte) (*Node, error) {
+	var n Node
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if n.Type == "" {
+		return nil, fmt.Errorf("%w: missing node type", ErrInvalid)
+	}
+	return &n, nil
+}
+
+// ParseValue parses a rich-text value decoded from JSON, such as a field of
+// Document.Payload. A string value is parsed as JSON.
+func ParseValue(v interface{}) (*Node, error) {
+	switch v := v.(type) {
+	case *Node:
+		return v, nil
+	case string:
+		return Parse([]byte(v))
+	case nil:
+		return nil, fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return Parse(b)
+}
+
+// Attr returns the string attribute key of the node, or "".
+func (n *Node) Attr(key string) string {
+	return stringAttr(n.Attrs, key)
+}
+
+// Attr returns the string attribute key of the mark, or "".
+func (m Mark) Attr(key string) string {
+	return stringAttr(m.Attrs, key)
+}
+
+// PlainText returns the text of the node and its descendants, with blocks
+// separated by newlines.
+func (n *Node) PlainText() string {
+	var sb strings.Builder
+	n.plainText(&sb)
+	return strings.TrimSpace(sb.String())
+}
+
+func (n *Node) plainText(sb *strings.Builder) {
+	switch n.Type {
+	case Text:
+		sb.WriteString(n.Text)
+		return
+	case HardBreak:
+		sb.WriteString("\n")
+		return
+	}
+	for _, c := range n.Content {
+		c.plainText(sb)
+	}
+	if isBlock(n.Type) {
+		sb.WriteString("\n")
+	}
+}
+
+// textContent returns the text of the node and its descendants as is, with
+// hard breaks as newlines.
+func (n *Node) textContent() string {
+	switch n.Type {
+	case Text:
+		return n.Text
+	case HardBreak:
+		return "\n"
+	}
+	var sb strings.Builder
+	for _, c := range n.Content {
+		sb.WriteString(c.textContent())
+	}
+	return sb.String()
+}
+
+func isBlock(typ string) bool {
+	switch typ {
+	case Paragraph, Heading, Blockquote, BulletList, OrderedList, ListItem, CodeBlock, HorizontalRule:
+		return true
+	}
+	return false
+}
+
+func stringAttr(attrs map[string]interface{}, key string) string {
+	switch v := attrs[key].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func intAttr(attrs map[string]interface{}, key string, def int) int {
+	if v, ok := attrs[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// markSpan tracks the marks open while rendering a run of inline nodes, so
+// that adjacent text nodes sharing a mark are wrapped once.
+type markSpan struct {
+	open  []Mark
+	start func(Mark) string
+	end   func(Mark) string
+}
+
+// next returns the closing and opening tags needed to move from the open
+// marks to marks.
+func (s *markSpan) next(marks []Mark) string {
+	keep := 0
+	for keep < len(s.open) && keep < len(marks) && sameMark(s.open[keep], marks[keep]) {
+		keep++
+	}
+	var sb strings.Builder
+	for i := len(s.open) - 1; i >= keep; i-- {
+		sb.WriteString(s.end(s.open[i]))
+	}
+	s.open = s.open[:keep]
+	for _, m := range marks[keep:] {
+		sb.WriteString(s.start(m))
+		s.open = append(s.open, m)
+	}
+	return sb.String()
+}
+
+func sameMark(a, b Mark) bool {
+	if a.Type != b.Type || len(a.Attrs) != len(b.Attrs) {
+		return false
+	}
+	for k, v := range a.Attrs {
+		if fmt.Sprint(b.Attrs[k]) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
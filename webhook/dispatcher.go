@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// Middleware wraps the handling of every event dispatched by a Dispatcher.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// PanicError is returned by Dispatch when a handler panics.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("webhook: handler panic: %v", e.Value)
+}
+
+// Dispatcher routes webhook events to handlers registered per event type.
+//
+// The zero value is ready to use. Handlers registered for the same event
+// type run in registration order; the first error stops the chain. The
+// status fields must be set before events are dispatched and not changed
+// afterwards.
+type Dispatcher struct {
+	// SuccessStatus is the response code when all handlers succeed. Defaults to 200.
+	SuccessStatus int
+	// FailureStatus is the response code when a handler fails or panics,
+	// prompting ContentZen to retry. Defaults to 500.
+	FailureStatus int
+	// UnhandledStatus is the response code for events with no registered
+	// handler. Defaults to 200 so unhandled events are not retried.
+	UnhandledStatus int
+
+	mu         sync.RWMutex
+	handlers   map[string][]HandlerFunc
+	middleware []Middleware
+}
+
+// NewDispatcher creates an empty Dispatcher.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Use appends middleware applied to every dispatched event. The first
+// middleware added is the outermost.
+func (d *Dispatcher) Use(mw ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.middleware = append(d.middleware, mw...)
+}
+
+// On registers fn for events of the given type.
+func (d *Dispatcher) On(eventType string, fn HandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.handlers == nil {
+		d.handlers = make(map[string][]HandlerFunc)
+	}
+	d.handlers[eventType] = append(d.handlers[eventType], fn)
+}
+
+// Handler returns an http.Handler that verifies deliveries with secret and
+// dispatches them with d. SuccessStatus is read once, when Handler is
+// called.
+func (d *Dispatcher) Handler(secret string) *Handler {
+	success := d.SuccessStatus
+	return NewHandler(secret, func(ctx context.Context, ev *Event) error {
+		if err := d.Dispatch(ctx, ev); err != nil {
+			return err
+		}
+		if success != 0 {
+			return &StatusError{Code: success}
+		}
+		return nil
+	})
+}
+
+// Dispatch runs the handlers registered for ev.Type through the middleware
+// chain. Handler errors and panics are returned as a *StatusError carrying
+// FailureStatus; events without handlers return a *StatusError carrying
+// UnhandledStatus if it is set.
+func (d *Dispatcher) Dispatch(ctx context.Context, ev *Event) error {
+	d.mu.RLock()
+	handlers := append([]HandlerFunc(nil), d.handlers[ev.Type]...)
+	middleware := append([]Middleware(nil), d.middleware...)
+	d.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		if d.UnhandledStatus != 0 {
+			return &StatusError{Code: d.UnhandledStatus}
+		}
+		return nil
+	}
+
+	var fn HandlerFunc = func(ctx context.Context, ev *Event) error {
+		for _, h := range handlers {
+			if err := h(ctx, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		fn = middleware[i](fn)
+	}
+
+	if err := recoverCall(ctx, ev, fn); err != nil {
+		code := d.FailureStatus
+		if code == 0 {
+			code = http.StatusInternalServerError
+		}
+		return &StatusError{Code: code, Err: err}
+	}
+	return nil
+}
+
+func recoverCall(ctx context.Context, ev *Event, fn HandlerFunc) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx, ev)
+}
+
+func (d *Dispatcher) onDocument(eventType string, fn func(context.Context, DocumentEvent) error) {
+	d.On(eventType, func(ctx context.Context, ev *Event) error {
+		var data DocumentEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return fmt.Errorf("webhook: decode %s data: %w", ev.Type, err)
+		}
+		return fn(ctx, data)
+	})
+}
+
+func (d *Dispatcher) onCollection(eventType string, fn func(context.Context, CollectionEvent) error) {
+	d.On(eventType, func(ctx context.Context, ev *Event) error {
+		var data CollectionEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return fmt.Errorf("webhook: decode %s data: %w", ev.Type, err)
+		}
+		return fn(ctx, data)
+	})
+}
+
+func (d *Dispatcher) onMedia(eventType string, fn func(context.Context, MediaEvent) error) {
+	d.On(eventType, func(ctx context.Context, ev *Event) error {
+		var data MediaEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return fmt.Errorf("webhook: decode %s data: %w", ev.Type, err)
+		}
+		return fn(ctx, data)
+	})
+}
+
+// OnDocumentCreated registers fn for document.created events.
+func (d *Dispatcher) OnDocumentCreated(fn func(context.Context, DocumentEvent) error) {
+	d.onDocument(contentzen.EventDocumentCreated, fn)
+}
+
+// OnDocumentUpdated registers fn for document.updated events.
+func (d *Dispatcher) OnDocumentUpdated(fn func(context.Context, DocumentEvent) error) {
+	d.onDocument(contentzen.EventDocumentUpdated, fn)
+}
+
+// OnDocumentDeleted registers fn for document.deleted events.
+func (d *Dispatcher) OnDocumentDeleted(fn func(context.Context, DocumentEvent) error) {
+	d.onDocument(contentzen.EventDocumentDeleted, fn)
+}
+
+// OnDocumentPublished registers fn for document.published events.
+func (d *Dispatcher) OnDocumentPublished(fn func(context.Context, DocumentEvent) error) {
+	d.onDocument(contentzen.EventDocumentPublished, fn)
+}
+
+// OnDocumentUnpublished registers fn for document.unpublished events.
+func (d *Dispatcher) OnDocumentUnpublished(fn func(context.Context, DocumentEvent) error) {
+	d.onDocument(contentzen.EventDocumentUnpublished, fn)
+}
+
+// OnCollectionCreated registers fn for collection.created events.
+func (d *Dispatcher) OnCollectionCreated(fn func(context.Context, CollectionEvent) error) {
+	d.onCollection(contentzen.EventCollectionCreated, fn)
+}
+
+// OnCollectionUpdated registers fn for collection.updated events.
+func (d *Dispatcher) OnCollectionUpdated(fn func(context.Context, CollectionEvent) error) {
+	d.onCollection(contentzen.EventCollectionUpdated, fn)
+}
+
+// OnCollectionDeleted registers fn for collection.deleted events.
+func (d *Dispatcher) OnCollectionDeleted(fn func(context.Context, CollectionEvent) error) {
+	d.onCollection(contentzen.EventCollectionDeleted, fn)
+}
+
+// OnMediaCreated registers fn for media.created events.
+func (d *Dispatcher) OnMediaCreated(fn func(context.Context, MediaEvent) error) {
+	d.onMedia(contentzen.EventMediaCreated, fn)
+}
+
+// OnMediaUpdated registers fn for media.updated events.
+func (d *Dispatcher) OnMediaUpdated(fn func(context.Context, MediaEvent) error) {
+	d.onMedia(contentzen.EventMediaUpdated, fn)
+}
+
+// OnMediaDeleted registers fn for media.deleted events.
+func (d *Dispatcher) OnMediaDeleted(fn func(context.Context, MediaEvent) error) {
+	d.onMedia(contentzen.EventMediaDeleted, fn)
+}
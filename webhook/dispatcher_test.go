@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDispatcherHandlerStatus(t *testing.T) {
+	d := NewDispatcher()
+	var err error
+	d.On("document.created", func(ctx context.Context, ev *Event) error { return err })
+	d.SuccessStatus = http.StatusAccepted
+	d.FailureStatus = http.StatusServiceUnavailable
+	d.UnhandledStatus = http.StatusNoContent
+	h := d.Handler("secret")
+	h.Now = func() time.Time { return testNow }
+
+	if got := deliver(h, "secret", testNow, `{"id":"evt_1","type":"document.created"}`, nil); got != http.StatusAccepted {
+		t.Errorf("success: status %d, want 202", got)
+	}
+	err = errors.New("boom")
+	if got := deliver(h, "secret", testNow, `{"id":"evt_2","type":"document.created"}`, nil); got != http.StatusServiceUnavailable {
+		t.Errorf("failure: status %d, want 503", got)
+	}
+
+	if got := deliver(h, "secret", testNow, `{"id":"evt_3","type":"media.created"}`, nil); got != http.StatusNoContent {
+		t.Errorf("unhandled: status %d, want 204", got)
+	}
+}
+
+func TestDispatcherPanic(t *testing.T) {
+	d := NewDispatcher()
+	d.On("document.created", func(ctx context.Context, ev *Event) error { panic("boom") })
+	err := d.Dispatch(context.Background(), &Event{Type: "document.created"})
+	var se *StatusError
+	var pe *PanicError
+	if !errors.As(err, &se) || se.Code != http.StatusInternalServerError || !errors.As(err, &pe) {
+		t.Errorf("Dispatch() = %#v", err)
+	}
+}
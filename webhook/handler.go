@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HandlerFunc processes a verified webhook event. Returning an error makes
+// the Handler respond with its FailureStatus so that ContentZen retries the
+// delivery; a *StatusError overrides the status code.
+type HandlerFunc func(ctx context.Context, ev *Event) error
+
+// StatusError makes the Handler respond with Code instead of its default
+// failure status. A Code below 300 acknowledges the delivery; a Code that
+// is not a valid HTTP status is treated as a failure.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("webhook: status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error { return e.Err }
+
+// Handler is an http.Handler that verifies, deduplicates and decodes
+// ContentZen webhook deliveries before passing them to OnEvent.
+type Handler struct {
+	// Secret is the shared secret configured on the webhook.
+	Secret string
+	// Tolerance is the maximum accepted clock difference between the
+	// signed timestamp and now. Defaults to DefaultTolerance; a negative
+	// value disables the timestamp check.
+	Tolerance time.Duration
+	// MaxBodyBytes limits the size of accepted bodies. Defaults to 1 MiB.
+	MaxBodyBytes int64
+	// OnEvent is called for every verified, non-replayed delivery.
+	OnEvent HandlerFunc
+	// SuccessStatus is the response code when OnEvent succeeds. Defaults to 200.
+	SuccessStatus int
+	// FailureStatus is the response code when OnEvent fails. Defaults to 500.
+	FailureStatus int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewHandler creates a webhook handler verifying deliveries with secret.
+// It panics if secret is empty.
+func NewHandler(secret string, fn HandlerFunc) *Handler {
+	if secret == "" {
+		panic(ErrEmptySecret)
+	}
+	return &Handler{
+		Secret:       secret,
+		Tolerance:    DefaultTolerance,
+		MaxBodyBytes: 1 << 20,
+		OnEvent:      fn,
+		Now:          time.Now,
+	}
+}
+
+// ServeHTTP implements http.Handler. It responds 401 for deliveries that
+// fail verification, 400 for malformed bodies, 413 for bodies larger than
+// MaxBodyBytes, 500 if no Secret is set and FailureStatus if OnEvent
+// fails.
+//
+// Deliveries that were already processed successfully are acknowledged
+// without calling OnEvent again. They are recognized by the event ID in
+// the signed body, or by the signature itself for events without one; the
+// unsigned DeliveryHeader is not trusted for this.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	maxBytes := h.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = 1 << 20
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "webhook: cannot read body", http.StatusBadRequest)
+		}
+		return
+	}
+	now := h.now()
+	if err := Verify(h.Secret, r.Header.Get(SignatureHeader), body, h.tolerance(), now); err != nil {
+		code := http.StatusUnauthorized
+		if errors.Is(err, ErrEmptySecret) {
+			code = http.StatusInternalServerError
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	var ev Event
+	if err := json.Unmarshal(body, &ev); err != nil {
+		http.Error(w, "webhook: malformed event", http.StatusBadRequest)
+		return
+	}
+
+	key := ev.ID
+	if key == "" {
+		key = r.Header.Get(SignatureHeader)
+	}
+	if err := h.reserve(key, now); err != nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var handlerErr error
+	if h.OnEvent != nil {
+		handlerErr = h.OnEvent(r.Context(), &ev)
+	}
+	code := h.status(handlerErr)
+	if code >= 300 {
+		h.release(key)
+		http.Error(w, "webhook: handler failed", code)
+		return
+	}
+	w.WriteHeader(code)
+}
+
+// status maps the result of OnEvent to a response code.
+func (h *Handler) status(err error) int {
+	var se *StatusError
+	if errors.As(err, &se) && se.Code >= 100 && se.Code <= 599 {
+		return se.Code
+	}
+	switch {
+	case err != nil:
+		if h.FailureStatus != 0 {
+			return h.FailureStatus
+		}
+		return http.StatusInternalServerError
+	case h.SuccessStatus != 0:
+		return h.SuccessStatus
+	default:
+		return http.StatusOK
+	}
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+func (h *Handler) tolerance() time.Duration {
+	if h.Tolerance < 0 {
+		return 0
+	}
+	if h.Tolerance == 0 {
+		return DefaultTolerance
+	}
+	return h.Tolerance
+}
+
+// reserve marks key as seen until it falls out of the tolerance window,
+// returning ErrReplayed if it is already marked.
+func (h *Handler) reserve(key string, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.seen == nil {
+		h.seen = make(map[string]time.Time)
+	}
+	for k, exp := range h.seen {
+		if now.After(exp) {
+			delete(h.seen, k)
+		}
+	}
+	if _, ok := h.seen[key]; ok {
+		return ErrReplayed
+	}
+	window := h.tolerance()
+	if window == 0 {
+		window = DefaultTolerance
+	}
+	h.seen[key] = now.Add(2 * window)
+	return nil
+}
+
+// release forgets key so that a retried delivery is processed again.
+func (h *Handler) release(key string) {
+	h.mu.Lock()
+	delete(h.seen, key)
+	h.mu.Unlock()
+}
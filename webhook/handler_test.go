@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testNow is the clock of the handlers under test.
+var testNow = time.Unix(1700000000, 0)
+
+// deliver sends body to h, signed with secret at t, and returns the
+// response code.
+func deliver(h http.Handler, secret string, t time.Time, body string, header http.Header) int {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if req.Header.Get(SignatureHeader) == "" {
+		req.Header.Set(SignatureHeader, Sign(secret, t, []byte(body)))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func newTestHandler(fn HandlerFunc) *Handler {
+	h := NewHandler("secret", fn)
+	h.Now = func() time.Time { return testNow }
+	return h
+}
+
+func TestHandlerVerifies(t *testing.T) {
+	calls := 0
+	h := newTestHandler(func(ctx context.Context, ev *Event) error {
+		calls++
+		if ev.ID != "evt_1" || ev.Type != "document.created" {
+			t.Errorf("OnEvent got %+v", ev)
+		}
+		return nil
+	})
+	body := `{"id":"evt_1","type":"document.created","data":{}}`
+
+	tests := []struct {
+		name   string
+		secret string
+		at     time.Time
+		header http.Header
+		want   int
+	}{
+		{name: "wrong secret", secret: "other", at: testNow, want: http.StatusUnauthorized},
+		{name: "missing signature", secret: "secret", at: testNow, header: http.Header{SignatureHeader: {"t=1"}}, want: http.StatusUnauthorized},
+		{name: "stale", secret: "secret", at: testNow.Add(-DefaultTolerance - time.Second), want: http.StatusUnauthorized},
+		{name: "future", secret: "secret", at: testNow.Add(DefaultTolerance + time.Second), want: http.StatusUnauthorized},
+		{name: "valid", secret: "secret", at: testNow.Add(-time.Minute), want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := deliver(h, tt.secret, tt.at, body, tt.header); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("OnEvent called %d times, want 1", calls)
+	}
+}
+
+func TestHandlerTolerance(t *testing.T) {
+	h := newTestHandler(nil)
+	h.Tolerance = time.Minute
+	body := `{"id":"evt_1","type":"document.created"}`
+	if got := deliver(h, "secret", testNow.Add(-2*time.Minute), body, nil); got != http.StatusUnauthorized {
+		t.Errorf("outside custom tolerance: status %d, want 401", got)
+	}
+	h.Tolerance = -1
+	if got := deliver(h, "secret", testNow.Add(-time.Hour), body, nil); got != http.StatusOK {
+		t.Errorf("with timestamp check disabled: status %d, want 200", got)
+	}
+}
+
+func TestHandlerReplay(t *testing.T) {
+	calls := 0
+	h := newTestHandler(func(ctx context.Context, ev *Event) error {
+		calls++
+		return nil
+	})
+	body := `{"id":"evt_1","type":"document.created"}`
+	sig := Sign("secret", testNow, []byte(body))
+
+	for i, delivery := range []string{"d1", "d2", ""} {
+		header := http.Header{SignatureHeader: {sig}}
+		if delivery != "" {
+			header.Set(DeliveryHeader, delivery)
+		}
+		if got := deliver(h, "", testNow, body, header); got != http.StatusOK {
+			t.Errorf("delivery %d: status %d, want 200", i, got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("OnEvent called %d times for a replayed body with new delivery IDs, want 1", calls)
+	}
+
+	// A different event is processed.
+	if got := deliver(h, "secret", testNow, `{"id":"evt_2","type":"document.created"}`, nil); got != http.StatusOK || calls != 2 {
+		t.Errorf("new event: status %d, %d calls", got, calls)
+	}
+
+	// Events without an ID are deduplicated by signature.
+	noID := `{"type":"document.created"}`
+	sig = Sign("secret", testNow, []byte(noID))
+	deliver(h, "", testNow, noID, http.Header{SignatureHeader: {sig}})
+	deliver(h, "", testNow, noID, http.Header{SignatureHeader: {sig}})
+	if calls != 3 {
+		t.Errorf("OnEvent called %d times, want 3", calls)
+	}
+}
+
+func TestHandlerReplayExpires(t *testing.T) {
+	calls := 0
+	h := newTestHandler(func(ctx context.Context, ev *Event) error {
+		calls++
+		return nil
+	})
+	body := `{"id":"evt_1","type":"document.created"}`
+	deliver(h, "secret", testNow, body, nil)
+	// Later, the same event ID is signed again by a retry.
+	h.Now = func() time.Time { return testNow.Add(3 * DefaultTolerance) }
+	deliver(h, "secret", testNow.Add(3*DefaultTolerance), body, nil)
+	if calls != 2 {
+		t.Errorf("OnEvent called %d times, want 2", calls)
+	}
+}
+
+func TestHandlerRetryAfterFailure(t *testing.T) {
+	fail := true
+	calls := 0
+	h := newTestHandler(func(ctx context.Context, ev *Event) error {
+		calls++
+		if fail {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	body := `{"id":"evt_1","type":"document.created"}`
+	if got := deliver(h, "secret", testNow, body, nil); got != http.StatusInternalServerError {
+		t.Errorf("failing handler: status %d, want 500", got)
+	}
+	fail = false
+	if got := deliver(h, "secret", testNow, body, nil); got != http.StatusOK {
+		t.Errorf("retry: status %d, want 200", got)
+	}
+	if calls != 2 {
+		t.Errorf("OnEvent called %d times, want 2", calls)
+	}
+}
+
+func TestHandlerStatusError(t *testing.T) {
+	var err error
+	h := newTestHandler(func(ctx context.Context, ev *Event) error { return err })
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&StatusError{Code: http.StatusServiceUnavailable}, http.StatusServiceUnavailable},
+		{&StatusError{Code: http.StatusAccepted}, http.StatusAccepted},
+		{&StatusError{Err: errors.New("no code")}, http.StatusInternalServerError},
+		{&StatusError{Code: 42}, http.StatusInternalServerError},
+		{&StatusError{Code: 1000}, http.StatusInternalServerError},
+	}
+	for i, tt := range tests {
+		err = tt.err
+		body := `{"id":"evt_` + string(rune('a'+i)) + `","type":"document.created"}`
+		if got := deliver(h, "secret", testNow, body, nil); got != tt.want {
+			t.Errorf("%v: status %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestHandlerRequests(t *testing.T) {
+	h := newTestHandler(nil)
+	h.MaxBodyBytes = 16
+
+	req := httptest.NewRequest(http.MethodGet, "/webhook", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET: status %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	if got := deliver(h, "secret", testNow, `{"id":"evt_1","type":"a.very.long.type"}`, nil); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: status %d, want 413", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook", io.MultiReader(strings.NewReader("{"), errReader{}))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unreadable body: status %d, want 400", rec.Code)
+	}
+
+	h.MaxBodyBytes = 0
+	if got := deliver(h, "secret", testNow, `not json`, nil); got != http.StatusBadRequest {
+		t.Errorf("malformed body: status %d, want 400", got)
+	}
+}
+
+func TestHandlerEmptySecret(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("NewHandler accepted an empty secret")
+			}
+		}()
+		NewHandler("", nil)
+	}()
+
+	h := &Handler{OnEvent: func(ctx context.Context, ev *Event) error {
+		t.Error("OnEvent called without a secret")
+		return nil
+	}}
+	body := `{"id":"evt_1","type":"document.created"}`
+	if got := deliver(h, "", time.Now(), body, nil); got != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", got)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
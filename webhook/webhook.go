@@ -0,0 +1,158 @@
+// Package webhook receives and verifies ContentZen webhook deliveries.
+//
+// Each delivery is signed with HMAC-SHA256 using the webhook's shared secret.
+// The signature is sent in the X-ContentZen-Signature header as
+//
+//	t=<unix timestamp>,v1=<hex signature>
+//
+// where the signature covers "<timestamp>.<request body>".
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/contentzen-hub/sdk-go/contentzen"
+)
+
+// Header names used by ContentZen webhook deliveries. The delivery ID
+// header is not covered by the signature.
+const (
+	SignatureHeader = "X-ContentZen-Signature"
+	DeliveryHeader  = "X-ContentZen-Delivery"
+)
+
+// DefaultTolerance is the maximum accepted age of a delivery's timestamp.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrEmptySecret is returned when verifying with an empty secret, which
+	// anyone could sign with.
+	ErrEmptySecret = errors.New("webhook: empty secret")
+	// ErrMissingSignature is returned when a delivery has no signature header.
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	// ErrInvalidSignature is returned when no signature matches the body.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrTimestampExpired is returned when the signed timestamp is outside the tolerance.
+	ErrTimestampExpired = errors.New("webhook: timestamp outside tolerance")
+	// ErrReplayed is returned when a delivery has already been accepted.
+	ErrReplayed = errors.New("webhook: delivery already received")
+)
+
+// Event is a webhook delivery as sent by ContentZen.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// DocumentEvent is the data of a document.* event.
+type DocumentEvent struct {
+	CollectionUUID string              `json:"collection_uuid"`
+	Document       contentzen.Document `json:"document"`
+}
+
+// CollectionEvent is the data of a collection.* event.
+type CollectionEvent struct {
+	Collection contentzen.Collection `json:"collection"`
+}
+
+// MediaEvent is the data of a media.* event.
+type MediaEvent struct {
+	Media contentzen.Media `json:"media"`
+}
+
+// Decode decodes the event data into a typed struct based on the event type:
+// *DocumentEvent, *CollectionEvent or *MediaEvent. Unknown event types
+// return the raw data as json.RawMessage.
+func (e *Event) Decode() (interface{}, error) {
+	var v interface{}
+	switch {
+	case strings.HasPrefix(e.Type, "document."):
+		v = &DocumentEvent{}
+	case strings.HasPrefix(e.Type, "collection."):
+		v = &CollectionEvent{}
+	case strings.HasPrefix(e.Type, "media."):
+		v = &MediaEvent{}
+	default:
+		return e.Data, nil
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return nil, fmt.Errorf("webhook: decode %s data: %w", e.Type, err)
+	}
+	return v, nil
+}
+
+// Sign returns the signature header value for body signed with secret at t.
+// It is useful for testing webhook receivers.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + computeSignature(secret, ts, body)
+}
+
+// Verify checks that header is a valid signature of body for secret,
+// signed no more than tolerance away from now. A zero tolerance disables
+// the timestamp check.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return ErrEmptySecret
+	}
+	if header == "" {
+		return ErrMissingSignature
+	}
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	expected := computeSignature(secret, ts, body)
+	valid := false
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampExpired
+		}
+	}
+	return nil
+}
+
+func computeSignature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
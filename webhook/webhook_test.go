@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1","type":"document.created"}`)
+	valid := Sign("secret", now, body)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		tolerance time.Duration
+		now       time.Time
+		want      error
+	}{
+		{name: "valid", secret: "secret", header: valid, body: body, tolerance: DefaultTolerance, now: now},
+		{name: "spaces between parts", secret: "secret", header: strings.ReplaceAll(valid, ",", ", "), body: body, tolerance: DefaultTolerance, now: now},
+		{name: "one of several signatures", secret: "secret", header: "t=" + ts + ",v1=deadbeef," + valid[strings.Index(valid, "v1="):], body: body, tolerance: DefaultTolerance, now: now},
+		{name: "wrong secret", secret: "other", header: valid, body: body, tolerance: DefaultTolerance, now: now, want: ErrInvalidSignature},
+		{name: "empty secret", secret: "", header: Sign("", now, body), body: body, tolerance: DefaultTolerance, now: now, want: ErrEmptySecret},
+		{name: "tampered body", secret: "secret", header: valid, body: []byte(`{"id":"evt_2","type":"document.created"}`), tolerance: DefaultTolerance, now: now, want: ErrInvalidSignature},
+		{name: "tampered timestamp", secret: "secret", header: strings.Replace(valid, "t="+ts, "t="+strconv.FormatInt(now.Unix()+1, 10), 1), body: body, tolerance: DefaultTolerance, now: now, want: ErrInvalidSignature},
+		{name: "missing header", secret: "secret", header: "", body: body, tolerance: DefaultTolerance, now: now, want: ErrMissingSignature},
+		{name: "missing timestamp", secret: "secret", header: valid[strings.Index(valid, "v1="):], body: body, tolerance: DefaultTolerance, now: now, want: ErrInvalidSignature},
+		{name: "missing signature", secret: "secret", header: "t=" + ts, body: body, tolerance: DefaultTolerance, now: now, want: ErrInvalidSignature},
+		{name: "malformed timestamp", secret: "secret", header: "t=abc,v1=00", body: body, tolerance: DefaultTolerance, now: now, want: ErrInvalidSignature},
+		{name: "inside tolerance", secret: "secret", header: valid, body: body, tolerance: DefaultTolerance, now: now.Add(DefaultTolerance)},
+		{name: "too old", secret: "secret", header: valid, body: body, tolerance: DefaultTolerance, now: now.Add(DefaultTolerance + time.Second), want: ErrTimestampExpired},
+		{name: "too far in the future", secret: "secret", header: valid, body: body, tolerance: DefaultTolerance, now: now.Add(-DefaultTolerance - time.Second), want: ErrTimestampExpired},
+		{name: "tolerance disabled", secret: "secret", header: valid, body: body, tolerance: 0, now: now.Add(24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.tolerance, tt.now)
+			if !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventDecode(t *testing.T) {
+	ev := &Event{Type: "document.updated", Data: []byte(`{"collection_uuid":"c1","document":{"uuid":"d1"}}`)}
+	v, err := ev.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := v.(*DocumentEvent)
+	if !ok || data.CollectionUUID != "c1" || data.Document.UUID != "d1" {
+		t.Errorf("Decode() = %#v", v)
+	}
+
+	ev = &Event{Type: "custom.event", Data: []byte(`{"a":1}`)}
+	if v, err := ev.Decode(); err != nil || string(v.(json.RawMessage)) != `{"a":1}` {
+		t.Errorf("Decode() of unknown type = %#v, %v", v, err)
+	}
+}